@@ -11,9 +11,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 
-	"github.com/coding-hui/common/util/homedir"
 	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
 
 	"github.com/coding-hui/wecoding-sdk-go/services/iam"
@@ -21,20 +19,13 @@ import (
 )
 
 func main() {
-	var iamconfig *string
-	if home := homedir.HomeDir(); home != "" {
-		iamconfig = flag.String(
-			"iamconfig",
-			filepath.Join(home, ".iam", "config"),
-			"(optional) absolute path to the iamconfig file",
-		)
-	} else {
-		iamconfig = flag.String("iamconfig", "", "absolute path to the iamconfig file")
-	}
+	// --iamconfig, --server, --token, ... override the loaded iamconfig
+	overrides := &clientcmd.ConfigOverrides{}
+	clientcmd.BindOverrideFlags(overrides, flag.CommandLine)
 	flag.Parse()
 
 	// use the current context in iamconfig
-	config, err := clientcmd.BuildConfigFromFlags("", *iamconfig)
+	config, err := clientcmd.BuildConfigFromOverrides(overrides)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -0,0 +1,7 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package informers provides a factory of shared informers which keep local,
+// indexed caches of iam resources in sync with the server.
+package informers // import "github.com/coding-hui/wecoding-sdk-go/informers"
@@ -0,0 +1,118 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package informers
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/informers/iam"
+	"github.com/coding-hui/wecoding-sdk-go/informers/internalinterfaces"
+	"github.com/coding-hui/wecoding-sdk-go/services"
+	"github.com/coding-hui/wecoding-sdk-go/tools/cache"
+)
+
+// SharedInformerFactory provides shared informers for resources in all known
+// API group versions.
+type SharedInformerFactory interface {
+	internalinterfaces.SharedInformerFactory
+
+	// Start initializes all requested informers. They are handled in goroutines
+	// which run until ctx is done.
+	Start(ctx context.Context)
+
+	// WaitForCacheSync blocks until all started informers' caches were synced
+	// or ctx is done.
+	WaitForCacheSync(ctx context.Context) map[reflect.Type]bool
+
+	Iam() iam.Interface
+}
+
+type sharedInformerFactory struct {
+	client        services.Interface
+	lock          sync.Mutex
+	defaultResync time.Duration
+
+	informers map[reflect.Type]cache.SharedIndexInformer
+	// startedInformers is used for tracking which informers have been started.
+	// This allows Start() to be called multiple times safely.
+	startedInformers map[reflect.Type]bool
+}
+
+// NewSharedInformerFactory constructs a new instance of sharedInformerFactory for all namespaces.
+func NewSharedInformerFactory(client services.Interface, defaultResync time.Duration) SharedInformerFactory {
+	return &sharedInformerFactory{
+		client:           client,
+		defaultResync:    defaultResync,
+		informers:        make(map[reflect.Type]cache.SharedIndexInformer),
+		startedInformers: make(map[reflect.Type]bool),
+	}
+}
+
+// Start initializes all requested informers.
+func (f *sharedInformerFactory) Start(ctx context.Context) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for informerType, informer := range f.informers {
+		if !f.startedInformers[informerType] {
+			go informer.Run(ctx)
+			f.startedInformers[informerType] = true
+		}
+	}
+}
+
+// WaitForCacheSync waits for all started informers' cache were synced.
+func (f *sharedInformerFactory) WaitForCacheSync(ctx context.Context) map[reflect.Type]bool {
+	informers := func() map[reflect.Type]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[reflect.Type]cache.SharedIndexInformer{}
+		for informerType, informer := range f.informers {
+			if f.startedInformers[informerType] {
+				informers[informerType] = informer
+			}
+		}
+
+		return informers
+	}()
+
+	res := map[reflect.Type]bool{}
+	for informType, informer := range informers {
+		res[informType] = cache.WaitForCacheSync(ctx, informer.HasSynced)
+	}
+
+	return res
+}
+
+// InformerFor returns the SharedIndexInformer for obj using an internal
+// client.
+func (f *sharedInformerFactory) InformerFor(
+	obj interface{},
+	newFunc internalinterfaces.NewInformerFunc,
+) cache.SharedIndexInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	informerType := reflect.TypeOf(obj)
+
+	informer, exists := f.informers[informerType]
+	if exists {
+		return informer
+	}
+
+	informer = newFunc(f.client, f.defaultResync)
+	f.informers[informerType] = informer
+
+	return informer
+}
+
+// Iam returns the informers of the iam group.
+func (f *sharedInformerFactory) Iam() iam.Interface {
+	return iam.New(f)
+}
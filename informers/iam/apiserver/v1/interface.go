@@ -0,0 +1,30 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package v1 provides shared informers for iam apiserver resources.
+package v1 // import "github.com/coding-hui/wecoding-sdk-go/informers/iam/apiserver/v1"
+
+import (
+	"github.com/coding-hui/wecoding-sdk-go/informers/internalinterfaces"
+)
+
+// Interface provides access to all the informers in this group version.
+type Interface interface {
+	// Users returns a UserInformer.
+	Users() UserInformer
+}
+
+type version struct {
+	factory internalinterfaces.SharedInformerFactory
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory) Interface {
+	return &version{factory: f}
+}
+
+// Users returns a UserInformer.
+func (v *version) Users() UserInformer {
+	return &userInformer{factory: v.factory}
+}
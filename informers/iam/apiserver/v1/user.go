@@ -0,0 +1,74 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/informers/internalinterfaces"
+	listerv1 "github.com/coding-hui/wecoding-sdk-go/listers/iam/apiserver/v1"
+	"github.com/coding-hui/wecoding-sdk-go/services"
+	"github.com/coding-hui/wecoding-sdk-go/tools/cache"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+// UserInformer provides access to a shared informer and lister for
+// Users.
+type UserInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() listerv1.UserLister
+}
+
+type userInformer struct {
+	factory internalinterfaces.SharedInformerFactory
+}
+
+// NewUserInformer constructs a new informer for User type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewUserInformer(client services.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	users := client.Iam().APIV1().Users()
+
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(ctx context.Context, options metav1.ListOptions) ([]interface{}, error) {
+				list, err := users.ListAll(ctx, options)
+				if err != nil {
+					return nil, err
+				}
+
+				items := make([]interface{}, 0, len(list))
+				for _, item := range list {
+					items = append(items, item)
+				}
+
+				return items, nil
+			},
+			WatchFunc: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+				return users.Watch(ctx, options)
+			},
+		},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func defaultUserInformer(client services.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewUserInformer(client, resyncPeriod, cache.Indexers{cache.NameIndex: cache.MetaNameIndexFunc})
+}
+
+// Informer returns the shared informer of users.
+func (f *userInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&v1.DetailUserResponse{}, defaultUserInformer)
+}
+
+// Lister returns a lister backed by the shared informer of users.
+func (f *userInformer) Lister() listerv1.UserLister {
+	return listerv1.NewUserLister(f.Informer().GetIndexer())
+}
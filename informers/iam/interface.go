@@ -0,0 +1,31 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package iam provides the informers of the iam group.
+package iam // import "github.com/coding-hui/wecoding-sdk-go/informers/iam"
+
+import (
+	v1 "github.com/coding-hui/wecoding-sdk-go/informers/iam/apiserver/v1"
+	"github.com/coding-hui/wecoding-sdk-go/informers/internalinterfaces"
+)
+
+// Interface provides access to each of this group's versions.
+type Interface interface {
+	// APIV1 provides access to shared informers for resources in APIV1.
+	APIV1() v1.Interface
+}
+
+type group struct {
+	factory internalinterfaces.SharedInformerFactory
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory) Interface {
+	return &group{factory: f}
+}
+
+// APIV1 returns a new v1.Interface.
+func (g *group) APIV1() v1.Interface {
+	return v1.New(g.factory)
+}
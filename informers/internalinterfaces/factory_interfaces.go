@@ -0,0 +1,22 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package internalinterfaces holds the interfaces shared by the informer
+// packages, so that they don't depend on the factory package.
+package internalinterfaces // import "github.com/coding-hui/wecoding-sdk-go/informers/internalinterfaces"
+
+import (
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/services"
+	"github.com/coding-hui/wecoding-sdk-go/tools/cache"
+)
+
+// NewInformerFunc takes services.Interface and time.Duration to return a SharedIndexInformer.
+type NewInformerFunc func(services.Interface, time.Duration) cache.SharedIndexInformer
+
+// SharedInformerFactory a small interface to allow for adding an informer without an import cycle.
+type SharedInformerFactory interface {
+	InformerFor(obj interface{}, newFunc NewInformerFunc) cache.SharedIndexInformer
+}
@@ -0,0 +1,6 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package v1 has listers which serve iam resources from a local cache.
+package v1 // import "github.com/coding-hui/wecoding-sdk-go/listers/iam/apiserver/v1"
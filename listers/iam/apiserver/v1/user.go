@@ -0,0 +1,77 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"strconv"
+
+	"github.com/coding-hui/common/fields"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/tools/cache"
+)
+
+// UserLister helps list users.
+// All objects returned here must be treated as read-only.
+type UserLister interface {
+	// List lists all users in the indexer which match the field selector.
+	// Objects returned here must be treated as read-only.
+	List(selector fields.Selector) (ret []*v1.DetailUserResponse, err error)
+	// Get retrieves the user from the index for a given instance id.
+	// Objects returned here must be treated as read-only.
+	Get(id string) (*v1.DetailUserResponse, error)
+	UserListerExpansion
+}
+
+// userLister implements the UserLister interface.
+type userLister struct {
+	indexer cache.Indexer
+}
+
+// NewUserLister returns a new UserLister.
+func NewUserLister(indexer cache.Indexer) UserLister {
+	return &userLister{indexer: indexer}
+}
+
+// List lists all users in the indexer which match the field selector.
+func (s *userLister) List(selector fields.Selector) (ret []*v1.DetailUserResponse, err error) {
+	for _, item := range s.indexer.List() {
+		user := item.(*v1.DetailUserResponse)
+		if selector == nil || selector.Matches(UserFields(user)) {
+			ret = append(ret, user)
+		}
+	}
+
+	return ret, nil
+}
+
+// Get retrieves the user from the index for a given instance id.
+func (s *userLister) Get(id string) (*v1.DetailUserResponse, error) {
+	obj, exists, err := s.indexer.GetByKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, rest.NewNotFound("user", id)
+	}
+
+	return obj.(*v1.DetailUserResponse), nil
+}
+
+// UserFields returns the fields of a user which can be used in field selectors.
+func UserFields(user *v1.DetailUserResponse) fields.Set {
+	return fields.Set{
+		"instanceId": user.InstanceID,
+		"name":       user.Name,
+		"alias":      user.Alias,
+		"email":      user.Email,
+		"phone":      user.Phone,
+		"userType":   user.UserType,
+		"status":     strconv.Itoa(user.Status),
+		"disabled":   strconv.FormatBool(user.Disabled),
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/tools/cache"
+)
+
+// UserListerExpansion allows custom methods to be added to UserLister.
+type UserListerExpansion interface {
+	// GetByName retrieves the user from the index for a given name.
+	// The indexer must have the cache.NameIndex index.
+	GetByName(name string) (*v1.DetailUserResponse, error)
+}
+
+// GetByName retrieves the user from the index for a given name.
+func (s *userLister) GetByName(name string) (*v1.DetailUserResponse, error) {
+	items, err := s.indexer.ByIndex(cache.NameIndex, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, rest.NewNotFound("user", name)
+	}
+
+	return items[0].(*v1.DetailUserResponse), nil
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package exec implements a rest.CredentialProvider running a local binary, e.g. an
+// SSO helper or a vault wrapper, to obtain the credentials of the requests.
+//
+// The binary is called with the IAM_EXEC_INFO environment variable set to an
+// ExecCredential object and must print an ExecCredential object to stdout:
+//
+//	{
+//	  "apiVersion": "client.authentication.wecoding.top/v1",
+//	  "kind": "ExecCredential",
+//	  "status": {
+//	    "token": "my-bearer-token",
+//	    "expirationTimestamp": "2024-01-02T15:04:05Z"
+//	  }
+//	}
+//
+// The credential is cached until it expires or the server rejects it. The
+// binary can only prompt the user, e.g. to log in through an SSO page, when the
+// interactiveMode of its config lets it read stdin, see InteractiveMode.
+package exec // import "github.com/coding-hui/wecoding-sdk-go/plugin/pkg/client/auth/exec"
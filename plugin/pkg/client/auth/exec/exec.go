@@ -0,0 +1,363 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+)
+
+const (
+	// APIVersionV1 is the version of the ExecCredential objects exchanged with the binary.
+	APIVersionV1 = "client.authentication.wecoding.top/v1"
+	// ExecInfoEnv is the environment variable holding the ExecCredential passed to the binary.
+	ExecInfoEnv = "IAM_EXEC_INFO"
+
+	execCredentialKind = "ExecCredential"
+)
+
+// DefaultExpiryDelta is how long before its expirationTimestamp a credential is
+// obtained again, so it doesn't expire while a request is sent.
+const DefaultExpiryDelta = 30 * time.Second
+
+// DefaultTimeout bounds a run of the command, whatever the deadlines of the
+// requests waiting for its credential. It leaves the user the time to log in
+// when the command prompts.
+const DefaultTimeout = 2 * time.Minute
+
+// InteractiveMode tells whether the command may prompt the user through stdin.
+type InteractiveMode string
+
+// Defines the interactive modes.
+const (
+	// NeverInteractiveMode never gives stdin to the command, it is the default.
+	NeverInteractiveMode InteractiveMode = "Never"
+	// IfAvailableInteractiveMode gives stdin to the command when it is a terminal.
+	IfAvailableInteractiveMode InteractiveMode = "IfAvailable"
+	// AlwaysInteractiveMode gives stdin to the command, which fails to run when
+	// stdin is not a terminal.
+	AlwaysInteractiveMode InteractiveMode = "Always"
+)
+
+// supportedAPIVersions are the ExecCredential versions understood by the Authenticator.
+var supportedAPIVersions = []string{APIVersionV1}
+
+// Config specifies a command to run to obtain credentials.
+type Config struct {
+	// Command to execute.
+	Command string `yaml:"command"               mapstructure:"command"`
+	// Args are the arguments passed to the command.
+	// +optional
+	Args []string `yaml:"args,omitempty"        mapstructure:"args,omitempty"`
+	// Env defines additional environment variables of the command.
+	// +optional
+	Env []EnvVar `yaml:"env,omitempty"         mapstructure:"env,omitempty"`
+	// APIVersion is the preferred version of the ExecCredential objects.
+	APIVersion string `yaml:"apiVersion,omitempty"  mapstructure:"apiVersion,omitempty"`
+	// InstallHint is printed when the command can't be found, it should tell
+	// the user how to install it.
+	// +optional
+	InstallHint string `yaml:"installHint,omitempty" mapstructure:"installHint,omitempty"`
+	// InteractiveMode tells whether the command may prompt the user through stdin,
+	// e.g. an SSO helper asking for a one-time code. Never by default.
+	// +optional
+	InteractiveMode InteractiveMode `yaml:"interactiveMode,omitempty" mapstructure:"interactiveMode,omitempty"`
+}
+
+// EnvVar is an environment variable of the command.
+type EnvVar struct {
+	Name  string `yaml:"name"  mapstructure:"name"`
+	Value string `yaml:"value" mapstructure:"value"`
+}
+
+// ExecCredential is exchanged with the command: it is passed to the command in
+// ExecInfoEnv with Spec set, and printed by the command with Status set.
+type ExecCredential struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Spec       ExecCredentialSpec    `json:"spec"`
+	Status     *ExecCredentialStatus `json:"status,omitempty"`
+}
+
+// ExecCredentialSpec holds the information passed to the command.
+type ExecCredentialSpec struct {
+	// Interactive is true when the command can prompt the user through stdin.
+	Interactive bool `json:"interactive"`
+}
+
+// ExecCredentialStatus holds the credentials obtained by the command.
+type ExecCredentialStatus struct {
+	// Token is the bearer token of the requests.
+	Token string `json:"token"`
+	// ExpirationTimestamp is when the token expires. If unset, the token is
+	// used until the server rejects it.
+	// +optional
+	ExpirationTimestamp *time.Time `json:"expirationTimestamp,omitempty"`
+}
+
+// Authenticator is a rest.CredentialProvider running a command to obtain
+// bearer tokens. It is safe for concurrent use: a single run of the command is
+// made at a time, without blocking the requests which have a valid token or
+// give up waiting for it.
+type Authenticator struct {
+	cmd             string
+	args            []string
+	env             []string
+	apiVersion      string
+	installHint     string
+	interactiveMode InteractiveMode
+
+	// for tests
+	environ func() []string
+	now     func() time.Time
+	stdin   *os.File
+
+	lock     sync.Mutex
+	token    string
+	obtained time.Time
+	expiry   time.Time
+	// fetching is the credential being obtained, nil if none is.
+	fetching *credentialFetch
+}
+
+// credentialFetch is a run of the command, shared by the requests waiting for it.
+type credentialFetch struct {
+	// done is closed once the credential is set or err is.
+	done chan struct{}
+	err  error
+}
+
+var _ rest.CredentialProvider = &Authenticator{}
+
+// NewAuthenticator returns an Authenticator running the command of config.
+func NewAuthenticator(config *Config) (*Authenticator, error) {
+	if len(config.Command) == 0 {
+		return nil, errors.New("exec plugin: command is required")
+	}
+
+	if !slices.Contains(supportedAPIVersions, config.APIVersion) {
+		return nil, fmt.Errorf("exec plugin: invalid apiVersion %q, supported versions are %v",
+			config.APIVersion, supportedAPIVersions)
+	}
+
+	interactiveMode := config.InteractiveMode
+	switch interactiveMode {
+	case "":
+		interactiveMode = NeverInteractiveMode
+	case NeverInteractiveMode, IfAvailableInteractiveMode, AlwaysInteractiveMode:
+	default:
+		return nil, fmt.Errorf("exec plugin: invalid interactiveMode %q, expected %q, %q or %q", interactiveMode,
+			NeverInteractiveMode, IfAvailableInteractiveMode, AlwaysInteractiveMode)
+	}
+
+	a := &Authenticator{
+		cmd:             config.Command,
+		args:            config.Args,
+		apiVersion:      config.APIVersion,
+		installHint:     config.InstallHint,
+		interactiveMode: interactiveMode,
+		environ:         os.Environ,
+		now:             time.Now,
+		stdin:           os.Stdin,
+	}
+
+	for _, env := range config.Env {
+		a.env = append(a.env, env.Name+"="+env.Value)
+	}
+
+	return a, nil
+}
+
+// Header implements rest.CredentialProvider.
+func (a *Authenticator) Header(ctx context.Context, _ *rest.CredentialRequest) (http.Header, error) {
+	a.lock.Lock()
+
+	if a.fresh(a.now()) {
+		defer a.lock.Unlock()
+
+		return http.Header{"Authorization": []string{"Bearer " + a.token}}, nil
+	}
+
+	fetch := a.fetching
+	if fetch == nil {
+		fetch = &credentialFetch{done: make(chan struct{})}
+		a.fetching = fetch
+
+		// the requests waiting for the credential don't depend on the first one
+		go a.fetch(context.WithoutCancel(ctx), fetch)
+	}
+
+	a.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-fetch.done:
+	}
+
+	if fetch.err != nil {
+		return nil, fetch.err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return http.Header{"Authorization": []string{"Bearer " + a.token}}, nil
+}
+
+// Invalidate implements rest.CredentialProvider.
+func (a *Authenticator) Invalidate(t time.Time) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.obtained.Before(t) {
+		a.token = ""
+	}
+
+	return true
+}
+
+// fresh returns whether the cached credential can still be used at now. It must
+// be called with the lock held.
+func (a *Authenticator) fresh(now time.Time) bool {
+	if len(a.token) == 0 {
+		return false
+	}
+
+	if a.expiry.IsZero() {
+		return true
+	}
+
+	return now.Add(DefaultExpiryDelta).Before(a.expiry)
+}
+
+// fetch runs the command, caches its credential and completes fetch. It is
+// called without the lock held.
+func (a *Authenticator) fetch(ctx context.Context, fetch *credentialFetch) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	now := a.now()
+	status, err := a.refresh(ctx)
+
+	a.lock.Lock()
+
+	if err == nil {
+		a.token = status.Token
+		a.obtained = now
+		a.expiry = time.Time{}
+
+		if status.ExpirationTimestamp != nil {
+			a.expiry = *status.ExpirationTimestamp
+		}
+	}
+
+	a.fetching = nil
+	a.lock.Unlock()
+
+	fetch.err = err
+	close(fetch.done)
+}
+
+// refresh runs the command and returns the credential it printed.
+func (a *Authenticator) refresh(ctx context.Context) (*ExecCredentialStatus, error) {
+	interactive, err := a.interactive()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := json.Marshal(&ExecCredential{
+		APIVersion: a.apiVersion,
+		Kind:       execCredentialKind,
+		Spec:       ExecCredentialSpec{Interactive: interactive},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := &bytes.Buffer{}
+
+	cmd := exec.CommandContext(ctx, a.cmd, a.args...)
+	cmd.Env = append(append(a.environ(), a.env...), ExecInfoEnv+"="+string(info))
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	if interactive {
+		cmd.Stdin = a.stdin
+	}
+
+	if err := cmd.Run(); err != nil {
+		return nil, a.wrapCmdRunErr(err)
+	}
+
+	var cred ExecCredential
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		return nil, fmt.Errorf("exec plugin: decoding stdout: %w", err)
+	}
+
+	switch {
+	case cred.APIVersion != a.apiVersion:
+		return nil, fmt.Errorf("exec plugin: output is of apiVersion %q, expected %q", cred.APIVersion, a.apiVersion)
+	case cred.Kind != execCredentialKind:
+		return nil, fmt.Errorf("exec plugin: output is of kind %q, expected %q", cred.Kind, execCredentialKind)
+	case cred.Status == nil || len(cred.Status.Token) == 0:
+		return nil, errors.New("exec plugin: output has no token")
+	}
+
+	return cred.Status, nil
+}
+
+// interactive returns whether the command is given stdin to prompt the user.
+func (a *Authenticator) interactive() (bool, error) {
+	switch a.interactiveMode {
+	case AlwaysInteractiveMode:
+		if !isTerminal(a.stdin) {
+			return false, errors.New("exec plugin: interactiveMode is Always but stdin is not a terminal")
+		}
+
+		return true, nil
+	case IfAvailableInteractiveMode:
+		return isTerminal(a.stdin), nil
+	default:
+		return false, nil
+	}
+}
+
+// isTerminal returns whether f is a terminal, i.e. a character device.
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// wrapCmdRunErr adds the install hint to the error of a command which can't be found.
+func (a *Authenticator) wrapCmdRunErr(err error) error {
+	if !errors.Is(err, exec.ErrNotFound) && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("exec plugin: running %q: %w", a.cmd, err)
+	}
+
+	msg := fmt.Sprintf("exec plugin: executable %s not found", a.cmd)
+	if len(a.installHint) != 0 {
+		msg += "\n\n" + strings.TrimSpace(a.installHint)
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
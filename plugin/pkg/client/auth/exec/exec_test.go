@@ -0,0 +1,262 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess is not a real test, it is run as the exec plugin by the
+// other tests. It prints the credential held by the HELPER_OUTPUT variable, after
+// the HELPER_SLEEP duration if set, and appends the ExecInfoEnv it got to the
+// HELPER_LOG file.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	f, err := os.OpenFile(os.Getenv("HELPER_LOG"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		os.Exit(2)
+	}
+	fmt.Fprintln(f, os.Getenv(ExecInfoEnv))
+	f.Close()
+
+	if sleep, err := time.ParseDuration(os.Getenv("HELPER_SLEEP")); err == nil {
+		time.Sleep(sleep)
+	}
+
+	fmt.Print(os.Getenv("HELPER_OUTPUT"))
+	os.Exit(0)
+}
+
+func newHelperAuthenticator(t *testing.T, output string, env ...EnvVar) (*Authenticator, string) {
+	t.Helper()
+
+	log := filepath.Join(t.TempDir(), "log")
+
+	a, err := NewAuthenticator(&Config{
+		Command:    os.Args[0],
+		Args:       []string{"-test.run=TestHelperProcess"},
+		APIVersion: APIVersionV1,
+		Env: append([]EnvVar{
+			{Name: "GO_WANT_HELPER_PROCESS", Value: "1"},
+			{Name: "HELPER_LOG", Value: log},
+			{Name: "HELPER_OUTPUT", Value: output},
+		}, env...),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return a, log
+}
+
+func credential(token string, expiry *time.Time) string {
+	data, _ := json.Marshal(ExecCredential{
+		APIVersion: APIVersionV1,
+		Kind:       execCredentialKind,
+		Status:     &ExecCredentialStatus{Token: token, ExpirationTimestamp: expiry},
+	})
+
+	return string(data)
+}
+
+func runs(t *testing.T, log string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return strings.Count(string(data), "\n")
+}
+
+func TestAuthenticator(t *testing.T) {
+	now := time.Now()
+	expiry := now.Add(time.Hour)
+
+	a, log := newHelperAuthenticator(t, credential("token", &expiry))
+	a.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		header, err := a.Header(context.TODO(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("expected Authorization %q, got %q", "Bearer token", got)
+		}
+	}
+
+	if got := runs(t, log); got != 1 {
+		t.Errorf("expected the credential to be cached, plugin ran %d times", got)
+	}
+
+	data, _ := os.ReadFile(log)
+	if !strings.Contains(string(data), `"kind":"ExecCredential"`) {
+		t.Errorf("expected the exec info to be passed to the plugin, got %q", data)
+	}
+
+	// the plugin runs again shortly before the credential expires
+	now = expiry.Add(-DefaultExpiryDelta)
+	if _, err := a.Header(context.TODO(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// or once the server rejected it
+	if !a.Invalidate(now.Add(time.Second)) {
+		t.Error("expected new credentials to be available")
+	}
+
+	if _, err := a.Header(context.TODO(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := runs(t, log); got != 3 {
+		t.Errorf("expected the plugin to run 3 times, got %d", got)
+	}
+}
+
+func TestAuthenticatorConcurrent(t *testing.T) {
+	a, log := newHelperAuthenticator(t, credential("token", nil), EnvVar{Name: "HELPER_SLEEP", Value: "500ms"})
+
+	// a request giving up doesn't stop the command for the others
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := a.Header(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+
+	if waited := time.Since(start); waited > 400*time.Millisecond {
+		t.Errorf("expected the request to give up at its deadline, waited %v", waited)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			header, err := a.Header(context.TODO(), nil)
+			if err != nil || header.Get("Authorization") != "Bearer token" {
+				t.Errorf("unexpected header %v: %v", header, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := runs(t, log); got != 1 {
+		t.Errorf("expected the plugin to run once, got %d", got)
+	}
+}
+
+func TestAuthenticatorInteractive(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "stdin"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer file.Close()
+
+	terminal, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer terminal.Close()
+
+	tests := []struct {
+		name            string
+		mode            InteractiveMode
+		stdin           *os.File
+		wantInteractive bool
+		wantErr         bool
+	}{
+		{name: "never", mode: NeverInteractiveMode, stdin: terminal},
+		{name: "if available without terminal", mode: IfAvailableInteractiveMode, stdin: file},
+		{name: "if available", mode: IfAvailableInteractiveMode, stdin: terminal, wantInteractive: true},
+		{name: "always", mode: AlwaysInteractiveMode, stdin: terminal, wantInteractive: true},
+		{name: "always without terminal", mode: AlwaysInteractiveMode, stdin: file, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, log := newHelperAuthenticator(t, credential("token", nil))
+			a.interactiveMode = tt.mode
+			a.stdin = tt.stdin
+
+			_, err := a.Header(context.TODO(), nil)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "not a terminal") {
+					t.Errorf("expected an error, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, _ := os.ReadFile(log)
+			if got := strings.Contains(string(data), `"interactive":true`); got != tt.wantInteractive {
+				t.Errorf("expected interactive %v, got exec info %q", tt.wantInteractive, data)
+			}
+		})
+	}
+
+	if _, err := NewAuthenticator(&Config{Command: "plugin", APIVersion: APIVersionV1, InteractiveMode: "Sometimes"}); err == nil {
+		t.Error("expected an error for an invalid interactiveMode")
+	}
+}
+
+func TestAuthenticatorErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "invalid output", output: "not json", want: "decoding stdout"},
+		{name: "wrong version", output: `{"apiVersion":"v0","kind":"ExecCredential"}`, want: "apiVersion"},
+		{name: "no token", output: credential("", nil), want: "no token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newHelperAuthenticator(t, tt.output)
+
+			_, err := a.Header(context.TODO(), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	a, err := NewAuthenticator(&Config{Command: "missing-iam-plugin", APIVersion: APIVersionV1, InstallHint: "install it"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := a.Header(context.TODO(), nil); err == nil || !strings.Contains(err.Error(), "install it") {
+		t.Errorf("expected the install hint in the error, got %v", err)
+	}
+
+	if _, err := NewAuthenticator(&Config{Command: "plugin", APIVersion: "v0"}); err == nil {
+		t.Error("expected an error for an unsupported apiVersion")
+	}
+}
@@ -5,13 +5,16 @@
 package rest
 
 import (
+	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/coding-hui/common/runtime"
 	"github.com/coding-hui/common/scheme"
 
-	"github.com/coding-hui/wecoding-sdk-go/third_party/forked/gorequest"
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+	"github.com/coding-hui/wecoding-sdk-go/util/flowcontrol"
 )
 
 // Interface captures the set of operations for generically interacting with IAM REST apis.
@@ -19,7 +22,7 @@ type Interface interface {
 	Verb(verb string) *Request
 	Post() *Request
 	Put() *Request
-	// Patch(pt types.PatchType) *Request
+	Patch(pt PatchType) *Request
 	Get() *Request
 	Delete() *Request
 	APIVersion() scheme.GroupVersion
@@ -38,9 +41,20 @@ type ClientContentConfig struct {
 	BearerToken string
 
 	// Path to a file containing a BearerToken.
-	// If set, the contents are periodically read.
+	// If set, the contents are read again every minute and when the server rejects the token.
 	// The last successfully read value takes precedence over BearerToken.
 	BearerTokenFile string
+
+	// TokenSource supplies the bearer token of the requests.
+	// If set, it takes precedence over BearerToken and BearerTokenFile.
+	TokenSource transport.ResettableTokenSource
+
+	// CredentialProvider authenticates the requests. It can't be combined
+	// with the other credentials.
+	CredentialProvider CredentialProvider
+
+	// Impersonate is the user the server evaluates the requests as.
+	Impersonate transport.ImpersonationConfig
 	TLSClientConfig
 
 	// AcceptContentTypes specifies the types the client will accept and is optional.
@@ -62,7 +76,7 @@ func (c *ClientContentConfig) HasBasicAuth() bool {
 
 // HasTokenAuth returns whether the configuration has token authentication or not.
 func (c *ClientContentConfig) HasTokenAuth() bool {
-	return len(c.BearerToken) != 0 || len(c.BearerTokenFile) != 0
+	return len(c.BearerToken) != 0 || len(c.BearerTokenFile) != 0 || c.TokenSource != nil
 }
 
 // HasKeyAuth returns whether the configuration has secretId/secretKey authentication or not.
@@ -101,13 +115,26 @@ type RESTClient struct {
 	versionedAPIPath string
 	// content describes how a RESTClient encodes and decodes responses.
 	content ClientContentConfig
-	Client  *gorequest.SuperAgent
+	// timeout is the default timeout of the requests, but not of the streams and
+	// watches; zero means no timeout.
+	timeout time.Duration
+	// retryPolicy is the default retry policy of the requests, nil disables retries.
+	retryPolicy *RetryPolicy
+	// rateLimiter paces the requests, nil disables rate limiting.
+	rateLimiter flowcontrol.RateLimiter
+	// credentials authenticates the requests, nil when the client has no credentials.
+	credentials CredentialProvider
+
+	// Set specific behavior of the client. If not set http.DefaultClient will be used.
+	// It is safe for concurrent use and may be shared by several clients.
+	Client *http.Client
 }
 
 // NewRESTClient creates a new RESTClient. This client performs generic REST functions
-// such as Get, Put, Post, and Delete on specified paths.
+// such as Get, Put, Post, and Delete on specified paths. A nil client means
+// http.DefaultClient.
 func NewRESTClient(baseURL *url.URL, versionedAPIPath string,
-	config ClientContentConfig, client *gorequest.SuperAgent) (*RESTClient, error) {
+	config ClientContentConfig, client *http.Client) (*RESTClient, error) {
 	if len(config.ContentType) == 0 {
 		config.ContentType = "application/json"
 	}
@@ -125,6 +152,7 @@ func NewRESTClient(baseURL *url.URL, versionedAPIPath string,
 		group:            config.GroupVersion.Group,
 		versionedAPIPath: versionedAPIPath,
 		content:          config,
+		credentials:      credentialProviderFor(config, config.GroupVersion.Group),
 		Client:           client,
 	}, nil
 }
@@ -144,6 +172,11 @@ func (c *RESTClient) Put() *Request {
 	return c.Verb("PUT")
 }
 
+// Patch begins a PATCH request. Short for c.Verb("PATCH") with the patch content type set.
+func (c *RESTClient) Patch(pt PatchType) *Request {
+	return c.Verb("PATCH").SetHeader("Content-Type", string(pt))
+}
+
 // Get begins a GET request. Short for c.Verb("GET").
 func (c *RESTClient) Get() *Request {
 	return c.Verb("GET")
@@ -0,0 +1,322 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coding-hui/common/runtime"
+	"github.com/coding-hui/common/scheme"
+
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+)
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		pt   PatchType
+		data string
+	}{
+		{pt: MergePatchType, data: `{"alias":"new alias","phone":null}`},
+		{pt: JSONPatchType, data: `[{"op":"replace","path":"/alias","value":"new alias"}]`},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.pt), func(t *testing.T) {
+			var method, contentType, body string
+			client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+				data, _ := io.ReadAll(req.Body)
+				method, contentType, body = req.Method, req.Header.Get("Content-Type"), string(data)
+				fmt.Fprint(w, `{"success":true}`)
+			})
+
+			err := client.Patch(tc.pt).Resource("users").Name("foo").Body([]byte(tc.data)).Do(context.TODO()).Error()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if method != http.MethodPatch {
+				t.Errorf("expected PATCH, got %s", method)
+			}
+			if contentType != string(tc.pt) {
+				t.Errorf("expected content type %q, got %q", tc.pt, contentType)
+			}
+			if body != tc.data {
+				t.Errorf("expected body %q, got %q", tc.data, body)
+			}
+
+			// A following request must not inherit the patch body or content type.
+			if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if body != "" || contentType != "" {
+				t.Errorf("expected empty body and content type, got %q and %q", body, contentType)
+			}
+		})
+	}
+}
+
+func TestBearerTokenFile(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("old\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	valid := "old"
+
+	var got []string
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		got = append(got, auth)
+
+		if auth != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprint(w, `{"code":0,"data":{}}`)
+	})
+	client.content.BearerTokenFile = tokenFile
+	client.credentials = credentialProviderFor(client.content, client.group)
+
+	if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the token is rotated, the cached one is rejected and the file read again
+	valid = "new"
+	if err := os.WriteFile(tokenFile, []byte("new\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"Bearer old", "Bearer old", "Bearer new"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected Authorization headers %v, got %v", want, got)
+	}
+}
+
+func TestReloadTLSFiles(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"code":0,"data":{}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := &Config{
+		Host: server.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+		TLSClientConfig: TLSClientConfig{CAFile: caFile, ReloadTLSFiles: true},
+	}
+
+	client, err := RESTClientFor(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := client.Client.Transport.(*certRotationRoundTripper); !ok {
+		t.Fatal("expected the TLS files to be reloaded")
+	}
+
+	// the server certificate is verified against the reloaded CA
+	if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the host name and the chain of the server are still verified
+	tests := []struct {
+		name       string
+		host       string
+		serverName string
+	}{
+		{name: "server name", host: server.URL, serverName: "wrong.example.org"},
+		{name: "host", host: strings.Replace(server.URL, "127.0.0.1", "localhost", 1)},
+	}
+
+	for _, tc := range tests {
+		wrong := CopyConfig(config)
+		wrong.Host, wrong.ServerName = tc.host, tc.serverName
+
+		wrongClient, err := RESTClientFor(wrong)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		if err := wrongClient.Get().Resource("users").Do(context.TODO()).Error(); err == nil ||
+			!strings.Contains(err.Error(), "certificate is valid for") {
+			t.Errorf("%s: expected the server certificate to be rejected, got %v", tc.name, err)
+		}
+	}
+
+	if err := os.WriteFile(caFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := RESTClientFor(config); err == nil {
+		t.Error("expected an invalid CA file to be rejected")
+	}
+}
+
+func TestWrapTransport(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		userAgent = req.Header.Get("User-Agent")
+		fmt.Fprint(w, `{"code":0,"data":{}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	var wrapped []string
+	config := &Config{
+		Host: server.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+		UserAgent: "test/1.0",
+		WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+			return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				wrapped = append(wrapped, req.Header.Get("User-Agent"))
+
+				return rt.RoundTrip(req)
+			})
+		},
+	}
+
+	client, err := RESTClientFor(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if userAgent != "test/1.0" {
+		t.Errorf("expected User-Agent %q, got %q", "test/1.0", userAgent)
+	}
+
+	// the wrapped round tripper sees the request as sent
+	if !reflect.DeepEqual(wrapped, []string{"test/1.0"}) {
+		t.Errorf("expected the request to go through the wrapper, got %v", wrapped)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// a forward proxy is sent the absolute URL of the request
+		proxied = append(proxied, req.URL.String())
+		fmt.Fprint(w, `{"code":0,"data":{}}`)
+	}))
+	t.Cleanup(proxy.Close)
+
+	proxyFunc, err := ProxyURL(proxy.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := &Config{
+		Host: "http://iam.example.com",
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+		Proxy: proxyFunc,
+	}
+
+	client, err := RESTClientFor(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(proxied) != 1 || !strings.HasPrefix(proxied[0], "http://iam.example.com/") {
+		t.Errorf("expected the request to be sent through the proxy, got %v", proxied)
+	}
+
+	for _, invalid := range []string{"ftp://proxy.example.com", "socks5://", "://"} {
+		if _, err := ProxyURL(invalid); err == nil {
+			t.Errorf("expected proxy URL %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.Header.Get("Authorization")+" as "+req.Header.Get(transport.ImpersonateUserHeader))
+		fmt.Fprint(w, `{"code":0,"data":{}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	config := &Config{
+		Host: server.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+		BearerToken: "service-account",
+	}
+
+	httpClient, err := HTTPClientFor(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the clients of every end user share the http client of the service account
+	for _, user := range []string{"alice", "bob"} {
+		userConfig := CopyConfig(config)
+		userConfig.Impersonate = transport.ImpersonationConfig{UserName: user, Groups: []string{"users"}}
+
+		client, err := RESTClientForConfigAndClient(userConfig, httpClient)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// a request can impersonate another user
+		err = client.Get().Resource("users").SetHeader(transport.ImpersonateUserHeader, "carol").Do(context.TODO()).Error()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := []string{
+		"Bearer service-account as alice",
+		"Bearer service-account as carol",
+		"Bearer service-account as bob",
+		"Bearer service-account as carol",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected requests %v, got %v", want, got)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	gruntime "runtime"
@@ -20,7 +21,8 @@ import (
 	"github.com/coding-hui/common/runtime"
 	"github.com/coding-hui/common/scheme"
 
-	"github.com/coding-hui/wecoding-sdk-go/third_party/forked/gorequest"
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+	"github.com/coding-hui/wecoding-sdk-go/util/flowcontrol"
 	"github.com/coding-hui/wecoding-sdk-go/version"
 )
 
@@ -44,18 +46,73 @@ type Config struct {
 	BearerToken string
 
 	// Path to a file containing a BearerToken.
-	// If set, the contents are periodically read.
+	// If set, the contents are read again every minute and when the server rejects the token.
 	// The last successfully read value takes precedence over BearerToken.
 	BearerTokenFile string
 
+	// TokenSource supplies the bearer token of the requests, e.g. a token obtained
+	// by logging in. It is reset when the server rejects its token.
+	// If set, it takes precedence over BearerToken and BearerTokenFile.
+	TokenSource transport.ResettableTokenSource
+
+	// CredentialProvider authenticates the requests, e.g. by signing them or by
+	// fetching tokens from a local agent. It can't be combined with the other
+	// credentials.
+	CredentialProvider CredentialProvider
+
+	// Impersonate is the user, groups and extra the server evaluates the requests
+	// as, instead of the authenticated user, which must be allowed to impersonate them.
+	Impersonate transport.ImpersonationConfig
+
 	// TLSClientConfig contains settings to enable transport layer security
 	TLSClientConfig
 
 	// UserAgent is an optional field that specifies the caller of this request.
+	// It is sent as the User-Agent header of the requests which don't set one.
 	UserAgent string
+
+	// WrapTransport will be invoked for custom HTTP behavior after the underlying
+	// transport is initialized (either the transport created from TLSClientConfig
+	// or the pooled default). The wrapped round tripper sees the requests with
+	// their User-Agent and credentials set, e.g. to sign or record them, and can
+	// be combined with others using transport.Wrappers.
+	// It is ignored by RESTClientForConfigAndClient, whose http.Client is used as is.
+	WrapTransport transport.WrapperFunc
+
+	// Proxy is the proxy func to be used for all requests made by this
+	// transport, e.g. the one returned by ProxyURL. If Proxy is nil,
+	// http.ProxyFromEnvironment is used: the HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	// environment variables are honored. If Proxy returns a nil *URL, no proxy is used.
+	//
+	// The http, https, socks5 and socks5h proxies are supported.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// Debug logs the requests and their responses with log/slog, with the
+	// credentials redacted, e.g. transport.DebugConfig{Level: transport.DebugBodies}.
+	Debug transport.DebugConfig
 	// The maximum length of time to wait before giving up on a server request. A value of zero means no timeout.
-	Timeout       time.Duration
-	MaxRetries    int
+	// It does not apply to the streams and watches, which last until closed unless
+	// the request sets a Timeout.
+	Timeout time.Duration
+
+	// QPS indicates the maximum QPS to the server from this client.
+	// If it's zero, the requests are not rate limited.
+	QPS float32
+	// Burst is the maximum burst for throttle.
+	// If it's zero and QPS is set, DefaultBurst is used.
+	Burst int
+	// RateLimiter paces the requests to the server from this client.
+	// If present, it overwrites QPS and Burst.
+	RateLimiter flowcontrol.RateLimiter
+
+	// RetryPolicy controls how failed requests are retried. If nil, MaxRetries
+	// and RetryInterval are used.
+	RetryPolicy *RetryPolicy
+	// MaxRetries is the maximum number of retries at a constant RetryInterval.
+	// Deprecated: use RetryPolicy instead.
+	MaxRetries int
+	// RetryInterval is the delay between retries.
+	// Deprecated: use RetryPolicy instead.
 	RetryInterval time.Duration
 }
 
@@ -126,6 +183,11 @@ type TLSClientConfig struct {
 	// CAData takes precedence over CAFile
 	CAData []byte
 
+	// ReloadTLSFiles reads CertFile, KeyFile and CAFile again when they change, so
+	// rotated certificates are used without restarting the process. The files are
+	// checked every transport.DefaultCertReloadInterval.
+	ReloadTLSFiles bool
+
 	// NextProtos is a list of supported application level protocols, in order of preference.
 	// Used to populate tls.Config.NextProtos.
 	// To indicate to the server http/1.1 is preferred over http/2, set to ["http/1.1", "h2"] (though the server is free
@@ -152,15 +214,16 @@ func (c TLSClientConfig) GoString() string {
 func (c TLSClientConfig) String() string {
 	// nolint: gosimple // no need
 	cc := sanitizedTLSClientConfig{
-		Insecure:   c.Insecure,
-		ServerName: c.ServerName,
-		CertFile:   c.CertFile,
-		KeyFile:    c.KeyFile,
-		CAFile:     c.CAFile,
-		CertData:   c.CertData,
-		KeyData:    c.KeyData,
-		CAData:     c.CAData,
-		NextProtos: c.NextProtos,
+		Insecure:       c.Insecure,
+		ServerName:     c.ServerName,
+		CertFile:       c.CertFile,
+		KeyFile:        c.KeyFile,
+		CAFile:         c.CAFile,
+		CertData:       c.CertData,
+		KeyData:        c.KeyData,
+		CAData:         c.CAData,
+		ReloadTLSFiles: c.ReloadTLSFiles,
+		NextProtos:     c.NextProtos,
 	}
 	// Explicitly mark non-empty credential fields as redacted.
 	if len(cc.CertData) != 0 {
@@ -189,6 +252,19 @@ func (c TLSClientConfig) HasCertAuth() bool {
 // A RESTClient created by this method is generic - it expects to operate on an API that follows
 // the IAM conventions, but may not be the IAM API.
 func RESTClientFor(config *Config) (*RESTClient, error) {
+	httpClient, err := HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return RESTClientForConfigAndClient(config, httpClient)
+}
+
+// RESTClientForConfigAndClient is like RESTClientFor but sends the requests with
+// httpClient, which may be shared by several clients, e.g. the group clients of a
+// clientset, to reuse its connections. The transport level security settings of
+// config are ignored, they are the ones of httpClient.
+func RESTClientForConfigAndClient(config *Config, httpClient *http.Client) (*RESTClient, error) {
 	if config.GroupVersion == nil {
 		return nil, fmt.Errorf("GroupVersion is required when initializing a RESTClient")
 	}
@@ -202,23 +278,6 @@ func RESTClientFor(config *Config) (*RESTClient, error) {
 		return nil, err
 	}
 
-	// Get the TLS options for this client config
-	tlsConfig, err := TLSConfigFor(config)
-	if err != nil {
-		return nil, err
-	}
-
-	// Only retry when get a server side error.
-	client := gorequest.New().TLSClientConfig(tlsConfig).Timeout(config.Timeout).
-		Retry(config.MaxRetries, config.RetryInterval, http.StatusInternalServerError)
-	// NOTICE: must set DoNotClearSuperAgent to true, or the client will clean header befor http.Do
-	client.DoNotClearSuperAgent = true
-
-	var gv scheme.GroupVersion
-	if config.GroupVersion != nil {
-		gv = *config.GroupVersion
-	}
-
 	clientContent := ClientContentConfig{
 		Username:           config.Username,
 		Password:           config.Password,
@@ -226,29 +285,95 @@ func RESTClientFor(config *Config) (*RESTClient, error) {
 		SecretKey:          config.SecretKey,
 		BearerToken:        config.BearerToken,
 		BearerTokenFile:    config.BearerTokenFile,
+		TokenSource:        config.TokenSource,
+		CredentialProvider: config.CredentialProvider,
+		Impersonate:        config.Impersonate,
 		TLSClientConfig:    config.TLSClientConfig,
 		AcceptContentTypes: config.AcceptContentTypes,
 		ContentType:        config.ContentType,
-		GroupVersion:       gv,
+		GroupVersion:       *config.GroupVersion,
 		Negotiator:         config.Negotiator,
 	}
 
-	return NewRESTClient(baseURL, versionedAPIPath, clientContent, client)
+	restClient, err := NewRESTClient(baseURL, versionedAPIPath, clientContent, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	restClient.timeout = config.Timeout
+	restClient.retryPolicy = retryPolicyFor(config)
+	restClient.rateLimiter = RateLimiterFor(config)
+
+	return restClient, nil
+}
+
+// DefaultBurst is the burst allowed when Config.QPS is set without Config.Burst.
+const DefaultBurst = 10
+
+// RateLimiterFor returns the rate limiter of config: its RateLimiter if set, otherwise
+// a token bucket rate limiter built from its QPS and Burst, or nil if QPS is not set.
+// Clients built from the same config share the limiter only when it is set on the config.
+func RateLimiterFor(config *Config) flowcontrol.RateLimiter {
+	if config.RateLimiter != nil {
+		return config.RateLimiter
+	}
+
+	if config.QPS <= 0 {
+		return nil
+	}
+
+	burst := config.Burst
+	if burst <= 0 {
+		burst = DefaultBurst
+	}
+
+	return flowcontrol.NewTokenBucketRateLimiter(config.QPS, burst)
 }
 
 // TLSConfigFor returns a tls.Config that will provide the transport level security defined
 // by the provided Config. Will return nil if no transport level security is requested.
 func TLSConfigFor(c *Config) (*tls.Config, error) {
+	tlsConfig, _, err := tlsConfigFor(c)
+
+	return tlsConfig, err
+}
+
+// tlsConfigFor is like TLSConfigFor but also returns the reloader of the files of c
+// when c.ReloadTLSFiles is set, nil otherwise.
+func tlsConfigFor(c *Config) (*tls.Config, *transport.CertificateReloader, error) {
 	if !(c.HasCA() || c.HasCertAuth() || c.Insecure || len(c.ServerName) > 0) {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	if c.HasCA() && c.Insecure {
-		return nil, fmt.Errorf("specifying a root certificates file with the insecure flag is not allowed")
+		return nil, nil, fmt.Errorf("specifying a root certificates file with the insecure flag is not allowed")
+	}
+
+	var certificates *transport.CertificateReloader
+
+	if files := reloadedTLSFiles(c); files != (transport.CertificateFiles{}) {
+		var err error
+
+		certificates, err = transport.NewCertificateReloader(files, transport.DefaultCertReloadInterval)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the reloaded files are not loaded once and for all below
+		reloaded := *c
+		if len(files.CAFile) != 0 {
+			reloaded.CAFile = ""
+		}
+
+		if len(files.CertFile) != 0 {
+			reloaded.CertFile, reloaded.KeyFile = "", ""
+		}
+
+		c = &reloaded
 	}
 
 	if err := LoadTLSFiles(c); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	tlsConfig := &tls.Config{
@@ -273,7 +398,7 @@ func TLSConfigFor(c *Config) (*tls.Config, error) {
 		// tlsConfig.GetClientCertificate.
 		cert, err := tls.X509KeyPair(c.CertData, c.KeyData)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		staticCert = &cert
@@ -294,7 +419,30 @@ func TLSConfigFor(c *Config) (*tls.Config, error) {
 		}
 	}
 
-	return tlsConfig, nil
+	if certificates != nil {
+		certificates.Configure(tlsConfig)
+	}
+
+	return tlsConfig, certificates, nil
+}
+
+// reloadedTLSFiles returns the files of c which are reloaded when they change: the
+// files which are set and not superseded by data, if c.ReloadTLSFiles is set.
+func reloadedTLSFiles(c *Config) transport.CertificateFiles {
+	var files transport.CertificateFiles
+	if !c.ReloadTLSFiles {
+		return files
+	}
+
+	if len(c.CAData) == 0 {
+		files.CAFile = c.CAFile
+	}
+
+	if len(c.CertData) == 0 && len(c.KeyData) == 0 && len(c.CertFile) != 0 && len(c.KeyFile) != 0 {
+		files.CertFile, files.KeyFile = c.CertFile, c.KeyFile
+	}
+
+	return files
 }
 
 // rootCertPool returns nil if caData is empty.  When passed along, this will mean "use system CAs".
@@ -432,27 +580,40 @@ func AddUserAgent(config *Config, userAgent string) *Config {
 // CopyConfig returns a copy of the given config.
 func CopyConfig(config *Config) *Config {
 	return &Config{
-		Host:            config.Host,
-		APIPath:         config.APIPath,
-		ContentConfig:   config.ContentConfig,
-		Username:        config.Username,
-		Password:        config.Password,
-		SecretID:        config.SecretID,
-		SecretKey:       config.SecretKey,
-		BearerToken:     config.BearerToken,
-		BearerTokenFile: config.BearerTokenFile,
+		Host:               config.Host,
+		APIPath:            config.APIPath,
+		ContentConfig:      config.ContentConfig,
+		Username:           config.Username,
+		Password:           config.Password,
+		SecretID:           config.SecretID,
+		SecretKey:          config.SecretKey,
+		BearerToken:        config.BearerToken,
+		BearerTokenFile:    config.BearerTokenFile,
+		TokenSource:        config.TokenSource,
+		CredentialProvider: config.CredentialProvider,
+		Impersonate:        config.Impersonate,
 		TLSClientConfig: TLSClientConfig{
-			Insecure:   config.TLSClientConfig.Insecure,
-			ServerName: config.TLSClientConfig.ServerName,
-			CertFile:   config.TLSClientConfig.CertFile,
-			KeyFile:    config.TLSClientConfig.KeyFile,
-			CAFile:     config.TLSClientConfig.CAFile,
-			CertData:   config.TLSClientConfig.CertData,
-			KeyData:    config.TLSClientConfig.KeyData,
-			CAData:     config.TLSClientConfig.CAData,
-			NextProtos: config.TLSClientConfig.NextProtos,
+			Insecure:       config.TLSClientConfig.Insecure,
+			ServerName:     config.TLSClientConfig.ServerName,
+			CertFile:       config.TLSClientConfig.CertFile,
+			KeyFile:        config.TLSClientConfig.KeyFile,
+			CAFile:         config.TLSClientConfig.CAFile,
+			CertData:       config.TLSClientConfig.CertData,
+			KeyData:        config.TLSClientConfig.KeyData,
+			CAData:         config.TLSClientConfig.CAData,
+			NextProtos:     config.TLSClientConfig.NextProtos,
+			ReloadTLSFiles: config.TLSClientConfig.ReloadTLSFiles,
 		},
-		UserAgent: config.UserAgent,
-		Timeout:   config.Timeout,
+		UserAgent:     config.UserAgent,
+		WrapTransport: config.WrapTransport,
+		Proxy:         config.Proxy,
+		Debug:         config.Debug,
+		Timeout:       config.Timeout,
+		QPS:           config.QPS,
+		Burst:         config.Burst,
+		RateLimiter:   config.RateLimiter,
+		RetryPolicy:   config.RetryPolicy,
+		MaxRetries:    config.MaxRetries,
+		RetryInterval: config.RetryInterval,
 	}
 }
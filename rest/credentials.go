@@ -0,0 +1,189 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coding-hui/common/util/auth"
+
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+)
+
+// CredentialRequest describes the request a CredentialProvider authenticates.
+// It must not be modified.
+type CredentialRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	// Body is the body sent as is, or the JSON encoding of the body object.
+	Body []byte
+}
+
+// CredentialProvider authenticates the requests of a client. Implementations
+// must be safe for concurrent use.
+type CredentialProvider interface {
+	// Header returns the headers authenticating req, e.g. an Authorization header.
+	Header(ctx context.Context, req *CredentialRequest) (http.Header, error)
+	// Invalidate is called when the server rejected credentials returned before t.
+	// It returns whether new credentials can be obtained, in which case the request
+	// is sent once more.
+	Invalidate(t time.Time) bool
+}
+
+// NewBasicAuthProvider returns a CredentialProvider sending the username and
+// password with the Basic authentication scheme.
+func NewBasicAuthProvider(username, password string) CredentialProvider {
+	return staticProvider{authorization: "Basic " + basicAuth(username, password)}
+}
+
+// NewBearerTokenProvider returns a CredentialProvider sending a static bearer token.
+func NewBearerTokenProvider(token string) CredentialProvider {
+	return staticProvider{authorization: "Bearer " + token}
+}
+
+// staticProvider sends the same Authorization header on every request.
+type staticProvider struct {
+	authorization string
+}
+
+// Header implements CredentialProvider.
+func (p staticProvider) Header(context.Context, *CredentialRequest) (http.Header, error) {
+	return http.Header{"Authorization": []string{p.authorization}}, nil
+}
+
+// Invalidate implements CredentialProvider.
+func (p staticProvider) Invalidate(time.Time) bool {
+	return false
+}
+
+// NewTokenSourceProvider returns a CredentialProvider sending the bearer tokens
+// of ts. The rejected tokens of a transport.ResettableTokenSource are reset.
+func NewTokenSourceProvider(ts transport.TokenSource) CredentialProvider {
+	return &tokenSourceProvider{source: ts}
+}
+
+// tokenSourceProvider sends the bearer tokens of a token source.
+type tokenSourceProvider struct {
+	source transport.TokenSource
+	// fallback is the token sent when the token source fails.
+	fallback string
+}
+
+// Header implements CredentialProvider.
+func (p *tokenSourceProvider) Header(ctx context.Context, _ *CredentialRequest) (http.Header, error) {
+	var token string
+
+	var err error
+
+	if ts, ok := p.source.(transport.ContextTokenSource); ok {
+		token, err = ts.TokenContext(ctx)
+	} else {
+		token, err = p.source.Token()
+	}
+
+	if err != nil {
+		if len(p.fallback) == 0 {
+			return nil, err
+		}
+
+		token = p.fallback
+	}
+
+	return http.Header{"Authorization": []string{"Bearer " + token}}, nil
+}
+
+// Invalidate implements CredentialProvider.
+func (p *tokenSourceProvider) Invalidate(t time.Time) bool {
+	ts, ok := p.source.(transport.ResettableTokenSource)
+	if ok {
+		ts.ResetTokenOlderThan(t)
+	}
+
+	return ok
+}
+
+// NewSecretKeyProvider returns a CredentialProvider sending a bearer token signed
+// with the secret key for the audience, e.g. "iam.api.wecoding.top". A new token
+// is signed for every request.
+func NewSecretKeyProvider(secretID, secretKey, audience string) CredentialProvider {
+	return secretKeyProvider{secretID: secretID, secretKey: secretKey, audience: audience}
+}
+
+// secretKeyProvider signs a bearer token for every request.
+type secretKeyProvider struct {
+	secretID  string
+	secretKey string
+	audience  string
+}
+
+// Header implements CredentialProvider.
+func (p secretKeyProvider) Header(context.Context, *CredentialRequest) (http.Header, error) {
+	token := auth.Sign(p.secretID, p.secretKey, "iam-sdk-go", p.audience)
+	return http.Header{"Authorization": []string{"Bearer " + token}}, nil
+}
+
+// Invalidate implements CredentialProvider.
+func (p secretKeyProvider) Invalidate(time.Time) bool {
+	return false
+}
+
+// credentialProviderFor returns the CredentialProvider of the client content for
+// the group, nil if it has no credentials.
+func credentialProviderFor(content ClientContentConfig, group string) CredentialProvider {
+	switch {
+	case content.CredentialProvider != nil:
+		return content.CredentialProvider
+	case content.TokenSource != nil:
+		return &tokenSourceProvider{source: content.TokenSource, fallback: content.BearerToken}
+	case len(content.BearerTokenFile) != 0:
+		return &tokenSourceProvider{
+			source:   transport.NewCachedFileTokenSource(content.BearerTokenFile),
+			fallback: content.BearerToken,
+		}
+	case len(content.BearerToken) != 0:
+		return NewBearerTokenProvider(content.BearerToken)
+	case content.HasKeyAuth():
+		return NewSecretKeyProvider(content.SecretID, content.SecretKey, group+".wecoding.top")
+	case content.HasBasicAuth():
+		return NewBasicAuthProvider(content.Username, content.Password)
+	default:
+		return nil
+	}
+}
+
+// validateCredentials returns an error if the client content has several kinds of credentials.
+func validateCredentials(content ClientContentConfig) error {
+	authMethod := 0
+
+	for _, fn := range []func() bool{content.HasBasicAuth, content.HasTokenAuth, content.HasKeyAuth} {
+		if fn() {
+			authMethod++
+		}
+	}
+
+	if content.CredentialProvider != nil {
+		authMethod++
+	}
+
+	if authMethod > 1 {
+		return fmt.Errorf(
+			"username/password or bearer token or secretID/secretKey or credential provider may be set, " +
+				"but should use only one of them",
+		)
+	}
+
+	return nil
+}
+
+func basicAuth(username, password string) string {
+	auth := username + ":" + password
+	return base64.StdEncoding.EncodeToString([]byte(auth))
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hmacProvider signs the method, path and body of the requests.
+type hmacProvider struct {
+	key         []byte
+	invalidated atomic.Int32
+}
+
+func (p *hmacProvider) sign(method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(method + "\n" + path + "\n"))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *hmacProvider) Header(_ context.Context, req *CredentialRequest) (http.Header, error) {
+	header := http.Header{}
+	header.Set("X-Signature", p.sign(req.Method, req.URL.Path, req.Body))
+
+	return header, nil
+}
+
+func (p *hmacProvider) Invalidate(time.Time) bool {
+	p.invalidated.Add(1)
+	return false
+}
+
+func TestCredentialProvider(t *testing.T) {
+	provider := &hmacProvider{key: []byte("secret")}
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if req.Header.Get("X-Signature") != provider.sign(req.Method, req.URL.Path, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprint(w, `{"code":0,"data":{}}`)
+	})
+	client.content.CredentialProvider = provider
+	client.credentials = credentialProviderFor(client.content, client.group)
+
+	body := struct {
+		Name string `json:"name"`
+	}{Name: "foo"}
+	if err := client.Post().Resource("users").Body(body).Do(context.TODO()).Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// an explicit Authorization header bypasses the provider
+	err := client.Get().Resource("users").SetHeader("Authorization", "Bearer foo").Do(context.TODO()).Error()
+	if !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+
+	if got := provider.invalidated.Load(); got != 0 {
+		t.Errorf("expected no invalidation, got %d", got)
+	}
+}
+
+func TestCredentialProviderFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		content ClientContentConfig
+		want    string
+	}{
+		{name: "basic", content: ClientContentConfig{Username: "foo", Password: "bar"}, want: "Basic Zm9vOmJhcg=="},
+		{name: "bearer token", content: ClientContentConfig{BearerToken: "token"}, want: "Bearer token"},
+		{name: "secret key", content: ClientContentConfig{SecretID: "id", SecretKey: "key"}, want: "Bearer "},
+		{name: "no credentials"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := credentialProviderFor(tt.content, "iam.api")
+			if provider == nil {
+				if len(tt.want) != 0 {
+					t.Fatal("expected a credential provider")
+				}
+
+				return
+			}
+
+			header, err := provider.Header(context.TODO(), &CredentialRequest{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := header.Get("Authorization"); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("expected Authorization %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	content := ClientContentConfig{BearerToken: "token", CredentialProvider: &hmacProvider{}}
+	if err := validateCredentials(content); err == nil {
+		t.Error("expected an error for several kinds of credentials")
+	}
+}
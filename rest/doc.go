@@ -3,5 +3,6 @@
 // license that can be found in the LICENSE file.
 
 // Package rest can used to deal with restful request.
-// Use gorequest as the http engine.
+// Requests are sent with net/http, over a pooled keep-alive transport which is
+// safe for concurrent use and may be shared by several clients.
 package rest
@@ -0,0 +1,223 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned by Request.Do when the server answers with a non-2xx
+// status code. It carries the HTTP status, the decoded CommonResponse fields and
+// enough request information to make the failure actionable.
+type StatusError struct {
+	// StatusCode is the HTTP status code returned by the server.
+	StatusCode int
+	// Code is the business error code decoded from CommonResponse.
+	Code int
+	// Message is the message decoded from CommonResponse, or the raw body
+	// when the body is not a CommonResponse.
+	Message string
+	// Reference is the reference document decoded from CommonResponse.
+	Reference string
+
+	// Method and URL describe the request which produced the error.
+	Method string
+	URL    string
+
+	// RetryAfter is the delay suggested by the Retry-After header, zero if absent.
+	RetryAfter time.Duration
+
+	// Body holds the raw response body.
+	Body []byte
+}
+
+var _ error = &StatusError{}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	msg := e.Message
+	if len(msg) == 0 {
+		msg = http.StatusText(e.StatusCode)
+	}
+
+	msg = fmt.Sprintf("%d %s", e.StatusCode, msg)
+	if e.Code != 0 {
+		msg = fmt.Sprintf("%s (code %d)", msg, e.Code)
+	}
+
+	if len(e.Method) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, msg)
+}
+
+// NewNotFound returns a new error which indicates that the resource of the
+// kind and the name was not found.
+func NewNotFound(resource, name string) *StatusError {
+	return &StatusError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("%s %q not found", resource, name),
+	}
+}
+
+// NewAlreadyExists returns a new error which indicates that the resource of the
+// kind and the name already exists.
+func NewAlreadyExists(resource, name string) *StatusError {
+	return &StatusError{
+		StatusCode: http.StatusConflict,
+		Message:    fmt.Sprintf("%s %q already exists", resource, name),
+	}
+}
+
+// NewBadRequest returns a new error which indicates that the request is invalid.
+func NewBadRequest(reason string) *StatusError {
+	return &StatusError{
+		StatusCode: http.StatusBadRequest,
+		Message:    reason,
+	}
+}
+
+// NewUnauthorized returns a new error which indicates that the request lacks
+// valid credentials.
+func NewUnauthorized(reason string) *StatusError {
+	return &StatusError{
+		StatusCode: http.StatusUnauthorized,
+		Message:    reason,
+	}
+}
+
+// newStatusError builds a StatusError from a response and its body.
+func newStatusError(method, url string, resp *http.Response, body []byte) *StatusError {
+	statusErr := &StatusError{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		URL:        url,
+		Body:       body,
+	}
+
+	var apiResp CommonResponse
+	if err := json.Unmarshal(body, &apiResp); err == nil && (apiResp.Code != 0 || len(apiResp.Msg) != 0) {
+		statusErr.Code = apiResp.Code
+		statusErr.Message = apiResp.Msg
+		statusErr.Reference = apiResp.Reference
+	} else {
+		statusErr.Message = strings.TrimSpace(string(body))
+	}
+
+	statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+
+	return statusErr
+}
+
+// parseRetryAfter parses the Retry-After header which is either a number of
+// seconds or an HTTP date. Invalid or past values yield zero.
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// statusErrorFor returns the StatusError wrapped in err, or nil.
+func statusErrorFor(err error) *StatusError {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr
+	}
+
+	return nil
+}
+
+// StatusCodeForError returns the HTTP status code carried by err, or 0 if err
+// is not a StatusError.
+func StatusCodeForError(err error) int {
+	if statusErr := statusErrorFor(err); statusErr != nil {
+		return statusErr.StatusCode
+	}
+
+	return 0
+}
+
+// CodeForError returns the business error code carried by err, or 0 if err
+// is not a StatusError.
+func CodeForError(err error) int {
+	if statusErr := statusErrorFor(err); statusErr != nil {
+		return statusErr.Code
+	}
+
+	return 0
+}
+
+// IsBadRequest returns true if the specified error was created by a 400 response.
+func IsBadRequest(err error) bool {
+	return StatusCodeForError(err) == http.StatusBadRequest
+}
+
+// IsUnauthorized returns true if the specified error was created by a 401 response.
+func IsUnauthorized(err error) bool {
+	return StatusCodeForError(err) == http.StatusUnauthorized
+}
+
+// IsForbidden returns true if the specified error was created by a 403 response.
+func IsForbidden(err error) bool {
+	return StatusCodeForError(err) == http.StatusForbidden
+}
+
+// IsNotFound returns true if the specified error was created by a 404 response.
+func IsNotFound(err error) bool {
+	return StatusCodeForError(err) == http.StatusNotFound
+}
+
+// IsConflict returns true if the specified error was created by a 409 response.
+func IsConflict(err error) bool {
+	return StatusCodeForError(err) == http.StatusConflict
+}
+
+// IsTooManyRequests returns true if the specified error was created by a 429 response.
+func IsTooManyRequests(err error) bool {
+	return StatusCodeForError(err) == http.StatusTooManyRequests
+}
+
+// IsInternalError returns true if the specified error was created by a 500 response.
+func IsInternalError(err error) bool {
+	return StatusCodeForError(err) == http.StatusInternalServerError
+}
+
+// IsServiceUnavailable returns true if the specified error was created by a 503 response.
+func IsServiceUnavailable(err error) bool {
+	return StatusCodeForError(err) == http.StatusServiceUnavailable
+}
+
+// SuggestsClientDelay returns the delay suggested by the server through the
+// Retry-After header and whether such a suggestion was made.
+func SuggestsClientDelay(err error) (time.Duration, bool) {
+	if statusErr := statusErrorFor(err); statusErr != nil && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coding-hui/common/runtime"
+	"github.com/coding-hui/common/scheme"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *RESTClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := RESTClientFor(&Config{
+		Host: server.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return client
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		retryAfter string
+		check      func(error) bool
+		code       int
+		message    string
+		delay      time.Duration
+	}{
+		{
+			name:    "not found with common response",
+			status:  http.StatusNotFound,
+			body:    `{"success":false,"code":100206,"msg":"user not found","reference":"https://docs"}`,
+			check:   IsNotFound,
+			code:    100206,
+			message: "user not found",
+		},
+		{
+			name:    "conflict with raw body",
+			status:  http.StatusConflict,
+			body:    "already exists\n",
+			check:   IsConflict,
+			message: "already exists",
+		},
+		{name: "unauthorized", status: http.StatusUnauthorized, check: IsUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden, check: IsForbidden},
+		{
+			name:       "too many requests",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "3",
+			check:      IsTooManyRequests,
+			delay:      3 * time.Second,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			})
+
+			err := client.Get().Resource("users").Name("foo").Do(context.TODO()).Error()
+			if !tc.check(err) {
+				t.Fatalf("unexpected error category: %v", err)
+			}
+
+			wrapped := fmt.Errorf("wrapped: %w", err)
+			if !tc.check(wrapped) {
+				t.Errorf("expected category to survive wrapping: %v", wrapped)
+			}
+
+			statusErr := statusErrorFor(err)
+			if statusErr.Method != http.MethodGet {
+				t.Errorf("expected method GET, got %q", statusErr.Method)
+			}
+			if e, a := tc.code, CodeForError(err); e != a {
+				t.Errorf("expected code %d, got %d", e, a)
+			}
+			if tc.message != "" && statusErr.Message != tc.message {
+				t.Errorf("expected message %q, got %q", tc.message, statusErr.Message)
+			}
+			if delay, ok := SuggestsClientDelay(err); delay != tc.delay || ok != (tc.delay > 0) {
+				t.Errorf("expected delay %v, got %v (%t)", tc.delay, delay, ok)
+			}
+		})
+	}
+}
+
+func TestStatusErrorSuccess(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"success":true,"data":{"name":"foo"}}`)
+	})
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := client.Post().Resource("users").Do(context.TODO()).Into(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.Name != "foo" {
+		t.Errorf("expected name foo, got %q", out.Name)
+	}
+}
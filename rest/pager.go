@@ -0,0 +1,249 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"iter"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+)
+
+// DefaultPageSize is the number of items requested per page when neither the
+// ListOptions nor the ListPager specify one.
+const DefaultPageSize = 100
+
+// ListPageFunc returns the page of items selected by the Offset and Limit of opts,
+// together with the total number of items of the list.
+type ListPageFunc[T any] func(ctx context.Context, opts metav1.ListOptions) (items []T, total int64, err error)
+
+// ListPager walks a list resource page by page using offset/limit pagination.
+// Offset pagination is not a consistent snapshot: items created or deleted
+// while paging may be missed or seen twice.
+type ListPager[T any] struct {
+	// PageSize is the number of items requested per page. It is used when the
+	// ListOptions have no Limit, zero means DefaultPageSize.
+	PageSize int64
+	// PageFn fetches a single page.
+	PageFn ListPageFunc[T]
+	// PageBufferSize is the number of pages fetched ahead in parallel once the
+	// total is known. Zero fetches pages sequentially.
+	PageBufferSize int
+}
+
+// NewListPager constructs a pager fetching pages with fn.
+func NewListPager[T any](fn ListPageFunc[T]) *ListPager[T] {
+	return &ListPager[T]{
+		PageSize: DefaultPageSize,
+		PageFn:   fn,
+	}
+}
+
+// List returns all the items starting at the Offset of opts.
+func (p *ListPager[T]) List(ctx context.Context, opts metav1.ListOptions) ([]T, error) {
+	var items []T
+
+	err := p.EachListItem(ctx, opts, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// EachListItem calls fn for every item starting at the Offset of opts. It stops
+// at the first error returned by a page fetch or by fn.
+func (p *ListPager[T]) EachListItem(ctx context.Context, opts metav1.ListOptions, fn func(T) error) error {
+	for item, err := range p.All(ctx, opts) {
+		if err != nil {
+			return err
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// All returns an iterator over the items starting at the Offset of opts. A failed
+// page fetch is yielded as a zero item with a non-nil error and ends the iteration.
+// Pages are only fetched as the iteration advances, breaking out of the loop
+// cancels any page fetched ahead.
+func (p *ListPager[T]) All(ctx context.Context, opts metav1.ListOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for items, err := range p.pages(ctx, opts) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pageResult is the outcome of a page fetched ahead.
+type pageResult[T any] struct {
+	items []T
+	err   error
+}
+
+// pages returns an iterator over the pages of the list.
+func (p *ListPager[T]) pages(ctx context.Context, opts metav1.ListOptions) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		limit := p.pageSize(opts)
+
+		var offset int64
+		if opts.Offset != nil {
+			offset = *opts.Offset
+		}
+
+		// Page and PageSize are an alternative to Offset and Limit, they must
+		// not override the window selected by the pager.
+		opts.Page = nil
+		opts.PageSize = nil
+
+		for {
+			items, total, err := p.fetch(ctx, opts, offset, limit)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(items, nil) {
+				return
+			}
+
+			offset += int64(len(items))
+			if !hasMore(int64(len(items)), offset, limit, total) {
+				return
+			}
+
+			// the server caps the page size below the limit, request what it returns
+			if int64(len(items)) < limit {
+				limit = int64(len(items))
+			}
+
+			// the remaining pages are known once the total is, fetch them ahead
+			if p.PageBufferSize > 0 && total > 0 {
+				var more bool
+				if offset, more = p.prefetch(ctx, opts, offset, limit, total, yield); !more {
+					return
+				}
+			}
+		}
+	}
+}
+
+// hasMore returns whether items follow a page of n items ending at offset. The
+// total is trusted when known, but an empty page always ends the list so that a
+// wrong total doesn't page forever. Without a total, a short page is the last one.
+func hasMore(n, offset, limit, total int64) bool {
+	if n == 0 {
+		return false
+	}
+
+	if total > 0 {
+		return offset < total
+	}
+
+	return n >= limit
+}
+
+// prefetch fetches the pages between offset and total in parallel, keeping at most
+// PageBufferSize pages ahead of the consumer, and yields them in order. If a page
+// is shorter than limit before the total is reached, the pages fetched after it
+// don't follow it: prefetch returns the offset following the short page and true
+// to continue from there.
+func (p *ListPager[T]) prefetch(
+	ctx context.Context,
+	opts metav1.ListOptions,
+	offset, limit, total int64,
+	yield func([]T, error) bool,
+) (int64, bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan chan pageResult[T], p.PageBufferSize)
+
+	go func(offset int64) {
+		defer close(results)
+
+		for ; offset < total; offset += limit {
+			result := make(chan pageResult[T], 1)
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(offset int64) {
+				items, _, err := p.fetch(ctx, opts, offset, limit)
+				result <- pageResult[T]{items: items, err: err}
+			}(offset)
+		}
+	}(offset)
+
+	for result := range results {
+		page := <-result
+		if page.err != nil {
+			yield(nil, page.err)
+			return offset, false
+		}
+
+		if !yield(page.items, nil) {
+			return offset, false
+		}
+
+		n := int64(len(page.items))
+		offset += n
+
+		if !hasMore(n, offset, limit, total) {
+			return offset, false
+		}
+
+		if n < limit {
+			return offset, true
+		}
+	}
+
+	return offset, false
+}
+
+// fetch requests the page of limit items starting at offset.
+func (p *ListPager[T]) fetch(ctx context.Context, opts metav1.ListOptions, offset, limit int64) ([]T, int64, error) {
+	opts.Offset = &offset
+	opts.Limit = &limit
+
+	return p.PageFn(ctx, opts)
+}
+
+// pageSize returns the number of items to request per page.
+func (p *ListPager[T]) pageSize(opts metav1.ListOptions) int64 {
+	if opts.Limit != nil && *opts.Limit > 0 {
+		return *opts.Limit
+	}
+
+	if p.PageSize > 0 {
+		return p.PageSize
+	}
+
+	return DefaultPageSize
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+)
+
+// testPageFunc serves the integers [0, total) and counts the fetched pages. Like
+// a server, it returns at most maxLimit items per page if maxLimit is non-zero.
+func testPageFunc(total, maxLimit int64, fetches *atomic.Int32) ListPageFunc[int64] {
+	return func(ctx context.Context, opts metav1.ListOptions) ([]int64, int64, error) {
+		fetches.Add(1)
+
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+
+		limit := *opts.Limit
+		if maxLimit > 0 && limit > maxLimit {
+			limit = maxLimit
+		}
+
+		var items []int64
+		for i := *opts.Offset; i < total && i < *opts.Offset+limit; i++ {
+			items = append(items, i)
+		}
+
+		return items, total, nil
+	}
+}
+
+func TestListPager(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int64
+		pageSize    int64
+		maxLimit    int64
+		buffer      int
+		opts        metav1.ListOptions
+		wantLen     int
+		wantFirst   int64
+		wantFetches int32
+	}{
+		{name: "empty", total: 0, pageSize: 10, wantLen: 0, wantFetches: 1},
+		{name: "exact pages", total: 30, pageSize: 10, wantLen: 30, wantFetches: 3},
+		{name: "partial last page", total: 25, pageSize: 10, wantLen: 25, wantFetches: 3},
+		{name: "limit overrides page size", total: 25, pageSize: 10, opts: metav1.ListOptions{Limit: ptr(int64(5))}, wantLen: 25, wantFetches: 5},
+		{name: "offset", total: 25, pageSize: 10, opts: metav1.ListOptions{Offset: ptr(int64(20))}, wantLen: 5, wantFirst: 20, wantFetches: 1},
+		{name: "prefetch", total: 95, pageSize: 10, buffer: 3, wantLen: 95, wantFetches: 10},
+		{name: "capped page size", total: 95, pageSize: 100, maxLimit: 30, wantLen: 95, wantFetches: 4},
+		{name: "capped page size prefetch", total: 95, pageSize: 20, maxLimit: 10, buffer: 3, wantLen: 95, wantFetches: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			pager := NewListPager(testPageFunc(tt.total, tt.maxLimit, &fetches))
+			pager.PageSize = tt.pageSize
+			pager.PageBufferSize = tt.buffer
+
+			items, err := pager.List(context.TODO(), tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(items) != tt.wantLen {
+				t.Fatalf("expected %d items, got %d", tt.wantLen, len(items))
+			}
+
+			for i, item := range items {
+				if item != tt.wantFirst+int64(i) {
+					t.Fatalf("unexpected item %d at %d", item, i)
+				}
+			}
+
+			if got := fetches.Load(); got != tt.wantFetches {
+				t.Errorf("expected %d fetches, got %d", tt.wantFetches, got)
+			}
+		})
+	}
+}
+
+func TestListPagerBreak(t *testing.T) {
+	var fetches atomic.Int32
+	pager := NewListPager(testPageFunc(1000, 0, &fetches))
+	pager.PageSize = 10
+
+	var seen int
+	for _, err := range pager.All(context.TODO(), metav1.ListOptions{}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		seen++
+		if seen == 15 {
+			break
+		}
+	}
+
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("expected 2 fetches, got %d", got)
+	}
+}
+
+func TestListPagerWrongTotal(t *testing.T) {
+	// the total counts more items than the server returns
+	pager := NewListPager(func(_ context.Context, opts metav1.ListOptions) ([]int64, int64, error) {
+		var items []int64
+		for i := *opts.Offset; i < 25 && i < *opts.Offset+*opts.Limit; i++ {
+			items = append(items, i)
+		}
+
+		return items, 1000, nil
+	})
+	pager.PageSize = 10
+
+	for _, buffer := range []int{0, 3} {
+		pager.PageBufferSize = buffer
+
+		items, err := pager.List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(items) != 25 {
+			t.Errorf("expected 25 items with buffer %d, got %d", buffer, len(items))
+		}
+	}
+}
+
+func TestListPagerError(t *testing.T) {
+	errPage := errors.New("page failed")
+	pager := NewListPager(func(_ context.Context, opts metav1.ListOptions) ([]int, int64, error) {
+		if *opts.Offset > 0 {
+			return nil, 0, errPage
+		}
+
+		return []int{1, 2}, 4, nil
+	})
+	pager.PageSize = 2
+
+	var visited int
+	err := pager.EachListItem(context.TODO(), metav1.ListOptions{}, func(int) error {
+		visited++
+		return nil
+	})
+	if !errors.Is(err, errPage) {
+		t.Errorf("expected page error, got %v", err)
+	}
+
+	if visited != 2 {
+		t.Errorf("expected 2 visited items, got %d", visited)
+	}
+}
+
+func TestVersionedParamsPerRequest(t *testing.T) {
+	var queries []string
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.RawQuery)
+	})
+
+	for _, offset := range []int64{0, 10} {
+		opts := metav1.ListOptions{Offset: ptr(offset), Limit: ptr(int64(10)), LabelSelector: "a=b"}
+		if err := client.Get().Resource("users").VersionedParams(opts).Do(context.TODO()).Error(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := []string{
+		"labelSelector=a%3Db&limit=10&offset=0",
+		"labelSelector=a%3Db&limit=10&offset=10",
+	}
+	if len(queries) != len(want) || queries[0] != want[0] || queries[1] != want[1] {
+		t.Errorf("expected queries %v, got %v", want, queries)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
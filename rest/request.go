@@ -5,22 +5,22 @@
 package rest
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/coding-hui/common/runtime"
-	"github.com/coding-hui/common/util/auth"
 
-	"github.com/coding-hui/wecoding-sdk-go/third_party/forked/gorequest"
+	"github.com/coding-hui/wecoding-sdk-go/transport"
 )
 
 // Request allows for building up a request to a server in a chained fashion.
@@ -29,7 +29,8 @@ import (
 type Request struct {
 	c *RESTClient
 
-	timeout time.Duration
+	timeout     time.Duration
+	retryPolicy *RetryPolicy
 
 	// generic components accessible via method setters
 	verb       string
@@ -60,37 +61,16 @@ func NewRequest(c *RESTClient) *Request {
 	}
 
 	r := &Request{
-		c:          c,
-		pathPrefix: pathPrefix,
+		c:           c,
+		pathPrefix:  pathPrefix,
+		retryPolicy: c.retryPolicy,
 	}
 
-	authMethod := 0
-
-	for _, fn := range []func() bool{c.content.HasBasicAuth, c.content.HasTokenAuth, c.content.HasKeyAuth} {
-		if fn() {
-			authMethod++
-		}
-	}
-
-	if authMethod > 1 {
-		r.err = fmt.Errorf(
-			"username/password or bearer token or secretID/secretKey may be set, but should use only one of them",
-		)
-
+	if err := validateCredentials(c.content); err != nil {
+		r.err = err
 		return r
 	}
 
-	switch {
-	case c.content.HasTokenAuth():
-		r.SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.content.BearerToken))
-	case c.content.HasKeyAuth():
-		tokenString := auth.Sign(c.content.SecretID, c.content.SecretKey, "iam-sdk-go", c.group+".wecoding.top")
-		r.SetHeader("Authorization", fmt.Sprintf("Bearer %s", tokenString))
-	case c.content.HasBasicAuth():
-		// TODO: get token and set header
-		r.SetHeader("Authorization", "Basic "+basicAuth(c.content.Username, c.content.Password))
-	}
-
 	// set accept content
 	switch {
 	case len(c.content.AcceptContentTypes) > 0:
@@ -102,18 +82,14 @@ func NewRequest(c *RESTClient) *Request {
 	return r
 }
 
-func basicAuth(username, password string) string {
-	auth := username + ":" + password
-	return base64.StdEncoding.EncodeToString([]byte(auth))
-}
-
 // NewRequestWithClient creates a Request with an embedded RESTClient for use in test scenarios.
 func NewRequestWithClient(base *url.URL, versionedAPIPath string,
-	content ClientContentConfig, client *gorequest.SuperAgent) *Request {
+	content ClientContentConfig, client *http.Client) *Request {
 	return NewRequest(&RESTClient{
 		base:             base,
 		versionedAPIPath: versionedAPIPath,
 		content:          content,
+		credentials:      credentialProviderFor(content, content.GroupVersion.Group),
 		Client:           client,
 	})
 }
@@ -286,11 +262,58 @@ func (r *Request) VersionedParams(v interface{}) *Request {
 		return r
 	}
 
-	r.c.Client.Query(v)
+	params, err := queryParams(v)
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	for k, values := range params {
+		for _, value := range values {
+			r.setParam(k, value)
+		}
+	}
 
 	return r
 }
 
+// queryParams converts the JSON representation of v into query parameters named
+// after the JSON fields of v.
+func queryParams(v interface{}) (url.Values, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+
+	for k, field := range fields {
+		switch value := field.(type) {
+		case nil:
+		case string:
+			params.Add(k, value)
+		case float64:
+			params.Add(k, strconv.FormatFloat(value, 'f', -1, 64))
+		case bool:
+			params.Add(k, strconv.FormatBool(value))
+		default:
+			j, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			params.Add(k, string(j))
+		}
+	}
+
+	return params, nil
+}
+
 func (r *Request) setParam(paramName, value string) *Request {
 	if r.params == nil {
 		r.params = make(url.Values)
@@ -317,7 +340,9 @@ func (r *Request) SetHeader(key string, values ...string) *Request {
 }
 
 // Timeout makes the request use the given duration as an overall timeout for the
-// request. Additionally, if set passes the value as "timeout" parameter in URL.
+// request, instead of the Timeout of the client config. Unlike the latter, it also
+// applies to streams and watches. Additionally, if set passes the value as "timeout"
+// parameter in URL.
 func (r *Request) Timeout(d time.Duration) *Request {
 	if r.err != nil {
 		return r
@@ -328,6 +353,18 @@ func (r *Request) Timeout(d time.Duration) *Request {
 	return r
 }
 
+// Retry overrides the retry policy of the client for this request. The overall
+// timeout of the request includes its retries. A nil policy disables retries.
+func (r *Request) Retry(policy *RetryPolicy) *Request {
+	if r.err != nil {
+		return r
+	}
+
+	r.retryPolicy = policy
+
+	return r
+}
+
 // URL returns the current working URL.
 func (r *Request) URL() *url.URL {
 	p := r.pathPrefix
@@ -366,6 +403,8 @@ func (r *Request) URL() *url.URL {
 }
 
 // Body makes the request use obj as the body. Optional.
+// If obj is a []byte it is sent as is, which is how patch documents are passed.
+// Otherwise obj is serialized according to the content type of the request.
 func (r *Request) Body(obj interface{}) *Request {
 	if v := reflect.ValueOf(obj); v.Kind() == reflect.Struct {
 		r.SetHeader("Content-Type", r.c.content.ContentType)
@@ -376,24 +415,169 @@ func (r *Request) Body(obj interface{}) *Request {
 	return r
 }
 
+// requestBody serializes the body of this request: []byte and string bodies are
+// sent as is, readers are read, other objects are encoded to JSON.
+func (r *Request) requestBody() ([]byte, error) {
+	switch body := r.body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return body, nil
+	case string:
+		return []byte(body), nil
+	case io.Reader:
+		return io.ReadAll(body)
+	default:
+		return json.Marshal(body)
+	}
+}
+
+// newHTTPRequest returns a new http.Request with the method, URL, headers and
+// body of this request. The body is serialized once, so that every attempt of
+// the request sends the same data.
+func (r *Request) newHTTPRequest(ctx context.Context, body []byte) (*http.Request, error) {
+	reqURL := r.URL()
+
+	header, err := r.header(ctx, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.verb, reqURL.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	if body != nil && len(req.Header.Get("Content-Type")) == 0 {
+		req.Header.Set("Content-Type", r.c.content.ContentType)
+	}
+
+	return req, nil
+}
+
+// httpClient returns the client sending the requests.
+func (r *Request) httpClient() *http.Client {
+	if r.c.Client == nil {
+		return http.DefaultClient
+	}
+
+	return r.c.Client
+}
+
+// authenticated returns whether the credentials of the client authenticate this
+// request, i.e. it has credentials and the Authorization header was not set explicitly.
+func (r *Request) authenticated() bool {
+	return r.c.credentials != nil && len(r.headers.Get("Authorization")) == 0
+}
+
+// header returns the headers of this request, including the impersonation headers
+// and the headers of the credential provider of the client.
+func (r *Request) header(ctx context.Context, reqURL *url.URL, body []byte) (http.Header, error) {
+	header := r.headers
+
+	// the impersonation headers are applied per client, not by its transport,
+	// so the clients of different users can share an http client
+	if impersonate := r.c.content.Impersonate; !impersonate.Empty() {
+		header = r.headers.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+
+		transport.SetImpersonationHeaders(header, impersonate)
+	}
+
+	if !r.authenticated() {
+		return header, nil
+	}
+
+	req := &CredentialRequest{
+		Method: r.verb,
+		URL:    reqURL,
+		Header: header,
+		Body:   body,
+	}
+
+	credentials, err := r.c.credentials.Header(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	for key, values := range credentials {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+
+	return header, nil
+}
+
+// refreshingCredentials calls fn, and calls it once more with new credentials if
+// the server rejected the ones of the credential provider and it can obtain others.
+func (r *Request) refreshingCredentials(fn func() error) error {
+	if !r.authenticated() {
+		return fn()
+	}
+
+	start := time.Now()
+
+	err := fn()
+	if !IsUnauthorized(err) || !r.c.credentials.Invalidate(start) {
+		return err
+	}
+
+	return fn()
+}
+
 // Do formats and executes the request. Returns a Result object for easy response processing.
 func (r *Request) Do(ctx context.Context) Result {
-	client := r.c.Client
-	client.Header = r.headers
+	if r.err != nil {
+		return Result{err: r.err}
+	}
 
-	if r.timeout > 0 {
+	timeout := r.timeout
+	if timeout == 0 {
+		timeout = r.c.timeout
+	}
+
+	if timeout > 0 {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 
 		defer cancel()
 	}
 
-	client.WithContext(ctx)
+	data, err := r.requestBody()
+	if err != nil {
+		return Result{err: err}
+	}
 
-	resp, body, errs := client.CustomMethod(r.verb, r.URL().String()).Send(r.body).EndBytes()
-	if err := combineErr(resp, body, errs); err != nil {
+	var (
+		resp *http.Response
+		body []byte
+	)
+
+	err = r.retry(ctx, func() error {
+		return r.refreshingCredentials(func() (err error) {
+			resp, body, err = r.doOnce(ctx, data)
+			return err
+		})
+	})
+	if err != nil {
 		return Result{
-			response: &resp,
+			response: resp,
 			err:      err,
 			body:     body,
 		}
@@ -402,7 +586,7 @@ func (r *Request) Do(ctx context.Context) Result {
 	decoder, err := r.c.content.Negotiator.Decoder()
 	if err != nil {
 		return Result{
-			response: &resp,
+			response: resp,
 			err:      err,
 			body:     body,
 			decoder:  decoder,
@@ -410,15 +594,131 @@ func (r *Request) Do(ctx context.Context) Result {
 	}
 
 	return Result{
-		response: &resp,
+		response: resp,
 		body:     body,
 		decoder:  decoder,
 	}
 }
 
+// doOnce sends the request once and reads the response. Responses with a non-2xx
+// status code are reported as *StatusError.
+func (r *Request) doOnce(ctx context.Context, data []byte) (*http.Response, []byte, error) {
+	req, err := r.newHTTPRequest(ctx, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp, body, newStatusError(r.verb, req.URL.String(), resp, body)
+	}
+
+	return resp, body, nil
+}
+
+// Stream formats and executes the request, and offers streaming of the response.
+// Returns io.ReadCloser which could be used for streaming of the response, or an error.
+// Any non-2xx http status code causes an error which is a *StatusError. The caller
+// is responsible for closing the returned stream.
+func (r *Request) Stream(ctx context.Context) (io.ReadCloser, error) {
+	resp, err := r.stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// stream executes the request without buffering the response. On success the
+// returned response body releases the request context when closed.
+func (r *Request) stream(ctx context.Context) (*http.Response, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// the timeout of the client would cut the stream, only the one of the request applies
+	cancel := func() {}
+	if r.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+	}
+
+	ctx = transport.WithStreamingResponse(ctx)
+
+	data, err := r.requestBody()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	var resp *http.Response
+
+	err = r.retry(ctx, func() error {
+		return r.refreshingCredentials(func() (err error) {
+			resp, err = r.streamOnce(ctx, data)
+			return err
+		})
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// streamOnce sends the request once and returns the response if its status is 2xx.
+func (r *Request) streamOnce(ctx context.Context, data []byte) (*http.Response, error) {
+	req, err := r.newHTTPRequest(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+		return nil, newStatusError(r.verb, req.URL.String(), resp, body)
+	}
+
+	return resp, nil
+}
+
+// maxErrorBodySize bounds how much of an error response body is read when streaming.
+const maxErrorBodySize = 1 << 20
+
+// cancelReadCloser releases the request context when the stream is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer.
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
 // Result contains the result of calling Request.Do().
 type Result struct {
-	response *gorequest.Response
+	response *http.Response
 	err      error
 	body     []byte
 	decoder  runtime.Decoder
@@ -462,25 +762,6 @@ func (r Result) Error() error {
 	return r.err
 }
 
-func combineErr(resp gorequest.Response, body []byte, errs []error) error {
-	var e, sep string
-
-	if len(errs) > 0 {
-		for _, err := range errs {
-			e = sep + err.Error()
-			sep = "\n"
-		}
-
-		return errors.New(e)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return errors.New(string(body))
-	}
-
-	return nil
-}
-
 // NameMayNotBe specifies strings that cannot be used as names specified as
 // path segments (like the REST API or etcd store).
 var NameMayNotBe = []string{".", ".."}
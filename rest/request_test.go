@@ -0,0 +1,128 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coding-hui/common/runtime"
+	"github.com/coding-hui/common/scheme"
+)
+
+func TestStream(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		flusher := w.(http.Flusher)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+			flusher.Flush()
+		}
+	})
+	client.content.BearerToken = "token"
+	client.credentials = credentialProviderFor(client.content, client.group)
+
+	stream, err := client.Get().Resource("exports").Timeout(time.Minute).Stream(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(lines) != 3 || lines[2] != "line 2" {
+		t.Errorf("unexpected lines: %v", lines)
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":110001,"msg":"export not found"}`)
+	})
+
+	stream, err := client.Get().Resource("exports").Name("foo").Stream(context.TODO())
+	if stream != nil {
+		t.Errorf("expected nil stream")
+	}
+
+	if !IsNotFound(err) || CodeForError(err) != 110001 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	var connections atomic.Int32
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Request") != req.URL.Query().Get("id") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, `{"code":0,"data":{}}`)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	client, err := RESTClientFor(&Config{
+		Host: server.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 10; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 20; i++ {
+				id := strconv.Itoa(worker*100 + i)
+
+				err := client.Get().Resource("users").Param("id", id).SetHeader("X-Request", id).Do(context.TODO()).Error()
+				if err != nil {
+					t.Errorf("request %s: unexpected error: %v", id, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := connections.Load(); got > DefaultMaxIdleConnsPerHost {
+		t.Errorf("expected the connections to be reused, got %d connections", got)
+	}
+}
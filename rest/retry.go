@@ -0,0 +1,300 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/util/flowcontrol"
+)
+
+// DefaultRetryableStatusCodes are the status codes retried when a RetryPolicy
+// does not list any.
+var DefaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Backoff computes the delay to wait before a retry.
+type Backoff interface {
+	// Delay returns the delay before the retry number attempt, starting at 1.
+	// prev is the delay returned for the previous attempt, zero for the first one.
+	Delay(attempt int, prev time.Duration) time.Duration
+}
+
+// ConstantBackoff waits the same interval before every retry.
+type ConstantBackoff struct {
+	Interval time.Duration
+}
+
+// Delay implements Backoff.
+func (b ConstantBackoff) Delay(int, time.Duration) time.Duration {
+	return b.Interval
+}
+
+// ExponentialBackoff multiplies the delay by Factor after every retry.
+type ExponentialBackoff struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+	// Max caps the delay, zero means no cap.
+	Max time.Duration
+	// Factor multiplies the delay after every retry, values below 1 mean 2.
+	Factor float64
+	// Jitter adds a random delay of up to Jitter times the delay.
+	Jitter float64
+}
+
+// Delay implements Backoff.
+func (b ExponentialBackoff) Delay(attempt int, _ time.Duration) time.Duration {
+	factor := b.Factor
+	if factor < 1 {
+		factor = 2
+	}
+
+	delay := float64(b.Initial) * math.Pow(factor, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		delay += rand.Float64() * b.Jitter * delay
+	}
+
+	return time.Duration(delay)
+}
+
+// DecorrelatedJitterBackoff picks every delay at random between Base and three
+// times the previous delay, which spreads out the retries of concurrent clients.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+type DecorrelatedJitterBackoff struct {
+	// Base is the minimum delay.
+	Base time.Duration
+	// Max caps the delay, zero means no cap.
+	Max time.Duration
+}
+
+// Delay implements Backoff.
+func (b DecorrelatedJitterBackoff) Delay(_ int, prev time.Duration) time.Duration {
+	upper := 3 * max(prev, b.Base)
+
+	delay := b.Base
+	if upper > b.Base {
+		delay += rand.N(upper - b.Base)
+	}
+
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	return delay
+}
+
+// RetryPolicy controls how failed requests are retried. Requests are retried
+// when the server answers with one of the retryable status codes or when the
+// connection fails, e.g. it is reset or times out. Non idempotent requests
+// (POST and PATCH) are only retried when they were not processed by the server,
+// i.e. on 429 responses and connection failures, unless RetryNonIdempotent is set.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries of a request, zero disables retries.
+	MaxRetries int
+	// Backoff computes the delay between retries, nil means no delay.
+	Backoff Backoff
+	// RetryableStatusCodes are the status codes which are retried.
+	// If empty, DefaultRetryableStatusCodes is used.
+	RetryableStatusCodes []int
+	// RetryNonIdempotent allows retrying POST and PATCH requests on any
+	// retryable failure.
+	RetryNonIdempotent bool
+	// MaxRetryAfter is the longest Retry-After delay the client agrees to wait,
+	// a longer one ends the retries. Zero means no limit.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy returns a policy retrying up to 3 times with an exponential
+// backoff starting at 200ms.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries: 3,
+		Backoff: ExponentialBackoff{
+			Initial: 200 * time.Millisecond,
+			Max:     5 * time.Second,
+			Factor:  2,
+			Jitter:  0.2,
+		},
+	}
+}
+
+// retryPolicyFor returns the retry policy of config. The deprecated MaxRetries and
+// RetryInterval fields are used when config has no RetryPolicy.
+func retryPolicyFor(config *Config) *RetryPolicy {
+	if config.RetryPolicy != nil {
+		return config.RetryPolicy
+	}
+
+	if config.MaxRetries <= 0 {
+		return nil
+	}
+
+	return &RetryPolicy{
+		MaxRetries: config.MaxRetries,
+		Backoff:    ConstantBackoff{Interval: config.RetryInterval},
+	}
+}
+
+// Delay returns how long to wait before the retry number attempt of a request
+// which failed with err, and whether the request should be retried at all.
+func (p *RetryPolicy) Delay(attempt int, prev time.Duration, verb string, err error) (time.Duration, bool) {
+	if p == nil || attempt > p.MaxRetries || !p.IsRetryable(verb, err) {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if p.Backoff != nil {
+		delay = p.Backoff.Delay(attempt, prev)
+	}
+
+	if retryAfter, ok := SuggestsClientDelay(err); ok {
+		if p.MaxRetryAfter > 0 && retryAfter > p.MaxRetryAfter {
+			return 0, false
+		}
+
+		delay = max(delay, retryAfter)
+	}
+
+	return delay, true
+}
+
+// IsRetryable returns whether a request with the verb which failed with err
+// may be retried.
+func (p *RetryPolicy) IsRetryable(verb string, err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if statusErr := statusErrorFor(err); statusErr != nil {
+		codes := p.RetryableStatusCodes
+		if len(codes) == 0 {
+			codes = DefaultRetryableStatusCodes
+		}
+
+		if !slices.Contains(codes, statusErr.StatusCode) {
+			return false
+		}
+
+		// the server turned the request down without processing it
+		return statusErr.StatusCode == http.StatusTooManyRequests || p.RetryNonIdempotent || isIdempotent(verb)
+	}
+
+	// the request never reached the server
+	if isDialError(err) {
+		return true
+	}
+
+	return isConnectionError(err) && (p.RetryNonIdempotent || isIdempotent(verb))
+}
+
+// isIdempotent returns whether requests with the verb can be safely repeated.
+func isIdempotent(verb string) bool {
+	switch verb {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDialError returns whether err happened while connecting to the server.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isConnectionError returns whether err is a connection failure which may be
+// fixed by a retry.
+func isConnectionError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retry calls fn until it succeeds or the request policy says to stop. Every call
+// is paced by the rate limiter of the client. It gives up as soon as ctx is done
+// and returns the last error of fn.
+func (r *Request) retry(ctx context.Context, fn func() error) error {
+	var delay time.Duration
+
+	for attempt := 1; ; attempt++ {
+		if err := r.tryThrottle(ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		r.observe(err)
+
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		var retry bool
+		if delay, retry = r.retryPolicy.Delay(attempt, delay, r.verb, err); !retry {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// tryThrottle waits for the rate limiter of the client, if any.
+func (r *Request) tryThrottle(ctx context.Context) error {
+	if r.c.rateLimiter == nil {
+		return nil
+	}
+
+	return r.c.rateLimiter.Wait(ctx)
+}
+
+// observe gives the outcome of a request to the rate limiter of the client when
+// it adapts its rate to the server: 429 responses slow it down, successful
+// requests let it recover.
+func (r *Request) observe(err error) {
+	limiter, ok := r.c.rateLimiter.(flowcontrol.AdaptiveRateLimiter)
+	if !ok {
+		return
+	}
+
+	switch {
+	case err == nil:
+		limiter.Succeeded()
+	case IsTooManyRequests(err):
+		retryAfter, _ := SuggestsClientDelay(err)
+		limiter.Throttled(retryAfter)
+	}
+}
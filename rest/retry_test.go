@@ -0,0 +1,201 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/util/flowcontrol"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		verb       string
+		status     int
+		retryAfter string
+		override   bool
+		policy     *RetryPolicy
+		wantCalls  int32
+		wantErr    bool
+	}{
+		{name: "retry until success", verb: http.MethodGet, status: http.StatusServiceUnavailable, wantCalls: 3},
+		{name: "status not retryable", verb: http.MethodGet, status: http.StatusInternalServerError, wantCalls: 1, wantErr: true},
+		{name: "post not retried", verb: http.MethodPost, status: http.StatusBadGateway, wantCalls: 1, wantErr: true},
+		{name: "post retried on 429", verb: http.MethodPost, status: http.StatusTooManyRequests, wantCalls: 3},
+		{
+			name:      "post retried when allowed",
+			verb:      http.MethodPost,
+			status:    http.StatusBadGateway,
+			override:  true,
+			policy:    &RetryPolicy{MaxRetries: 3, RetryNonIdempotent: true},
+			wantCalls: 3,
+		},
+		{
+			name:      "custom status codes",
+			verb:      http.MethodGet,
+			status:    http.StatusInternalServerError,
+			override:  true,
+			policy:    &RetryPolicy{MaxRetries: 3, RetryableStatusCodes: []int{http.StatusInternalServerError}},
+			wantCalls: 3,
+		},
+		{
+			name:      "retries exhausted",
+			verb:      http.MethodGet,
+			status:    http.StatusServiceUnavailable,
+			override:  true,
+			policy:    &RetryPolicy{MaxRetries: 1},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+		{
+			name:       "retry after too long",
+			verb:       http.MethodGet,
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "120",
+			override:   true,
+			policy:     &RetryPolicy{MaxRetries: 3, MaxRetryAfter: time.Second},
+			wantCalls:  1,
+			wantErr:    true,
+		},
+		{name: "disabled per request", verb: http.MethodGet, status: http.StatusServiceUnavailable, override: true, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+				if calls.Add(1) < 3 {
+					if len(tt.retryAfter) != 0 {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+
+					w.WriteHeader(tt.status)
+
+					return
+				}
+
+				w.Write([]byte(`{"code":0,"data":{}}`))
+			})
+			client.retryPolicy = &RetryPolicy{MaxRetries: 3, Backoff: ConstantBackoff{Interval: time.Millisecond}}
+
+			req := client.Verb(tt.verb).Resource("users")
+			if tt.override {
+				req.Retry(tt.policy)
+			}
+
+			err := req.Do(context.TODO()).Error()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestRetryStream(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	})
+	client.retryPolicy = &RetryPolicy{MaxRetries: 1}
+
+	stream, err := client.Get().Resource("exports").Stream(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stream.Close()
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	exponential := ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second, Factor: 2}
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		if got := exponential.Delay(attempt+1, 0); got != want*time.Millisecond {
+			t.Errorf("attempt %d: expected %v, got %v", attempt+1, want*time.Millisecond, got)
+		}
+	}
+
+	jitter := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+
+	var prev time.Duration
+	for attempt := 1; attempt <= 20; attempt++ {
+		delay := jitter.Delay(attempt, prev)
+		if delay < jitter.Base || delay > jitter.Max || delay > 3*max(prev, jitter.Base) {
+			t.Fatalf("attempt %d: delay %v out of bounds (previous %v)", attempt, delay, prev)
+		}
+
+		prev = delay
+	}
+}
+
+func TestRetryPolicyFor(t *testing.T) {
+	if policy := retryPolicyFor(&Config{}); policy != nil {
+		t.Errorf("expected no retry policy, got %+v", policy)
+	}
+
+	policy := retryPolicyFor(&Config{MaxRetries: 2, RetryInterval: time.Second})
+	if policy == nil || policy.MaxRetries != 2 || policy.Backoff != (ConstantBackoff{Interval: time.Second}) {
+		t.Errorf("unexpected retry policy: %+v", policy)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Write([]byte(`{"code":0,"data":{}}`))
+	})
+	limiter := flowcontrol.NewTokenBucketRateLimiter(100, 1)
+	client.rateLimiter = limiter
+	client.retryPolicy = &RetryPolicy{MaxRetries: 1}
+
+	if err := client.Get().Resource("users").Do(context.TODO()).Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// throttled once, then recovered by the successful retry
+	if got, want := limiter.QPS(), float32(60); got != want {
+		t.Errorf("expected qps %v, got %v", want, got)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+}
+
+func TestRateLimiterFor(t *testing.T) {
+	if limiter := RateLimiterFor(&Config{}); limiter != nil {
+		t.Errorf("expected no rate limiter, got %v", limiter)
+	}
+
+	if limiter := RateLimiterFor(&Config{QPS: 5}); limiter == nil || limiter.QPS() != 5 {
+		t.Errorf("unexpected rate limiter: %v", limiter)
+	}
+
+	shared := flowcontrol.NewTokenBucketRateLimiter(1, 1)
+	if limiter := RateLimiterFor(&Config{QPS: 5, RateLimiter: shared}); limiter != shared {
+		t.Errorf("expected the config rate limiter, got %v", limiter)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+)
+
+// Defines the connection pool of the transports built by TransportFor.
+const (
+	// DefaultMaxIdleConns is the maximum number of idle connections across all hosts.
+	DefaultMaxIdleConns = 100
+	// DefaultMaxIdleConnsPerHost is the maximum number of idle connections kept to a host.
+	DefaultMaxIdleConnsPerHost = 25
+	// DefaultIdleConnTimeout is how long an idle connection is kept.
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// HTTPClientFor returns an http.Client for the config. It is safe for concurrent
+// use: the clients sharing it reuse the connections of its transport, see
+// RESTClientForConfigAndClient. It has no timeout, which would cut the streams
+// and watches, the Timeout of the config is applied by the requests of the clients.
+func HTTPClientFor(config *Config) (*http.Client, error) {
+	rt, err := TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: rt}, nil
+}
+
+// TransportFor returns an http.RoundTripper sending the requests with the transport
+// level security of the config over a pool of keep-alive connections, wrapped by
+// the WrapTransport of the config and HTTPWrappersForConfig.
+func TransportFor(config *Config) (http.RoundTripper, error) {
+	tlsConfig, certificates, err := tlsConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var rt http.RoundTripper
+
+	if certificates != nil {
+		rt = newCertRotationRoundTripper(certificates, tlsConfig, config.Proxy)
+	} else {
+		rt = newTransport(tlsConfig, config.Proxy)
+	}
+
+	if config.WrapTransport != nil {
+		rt = config.WrapTransport(rt)
+	}
+
+	return HTTPWrappersForConfig(config, rt), nil
+}
+
+// HTTPWrappersForConfig wraps a round tripper with the behavior the config requires
+// from every request: logging them as the Debug config requires, with the headers
+// as sent, and setting their User-Agent header.
+func HTTPWrappersForConfig(config *Config, rt http.RoundTripper) http.RoundTripper {
+	// the debug logs are wrapped by the User-Agent, so they include it
+	if config.Debug.Enabled() {
+		rt = transport.NewDebuggingRoundTripper(config.Debug, rt)
+	}
+
+	if len(config.UserAgent) != 0 {
+		rt = transport.NewUserAgentRoundTripper(config.UserAgent, rt)
+	}
+
+	return rt
+}
+
+// ProxyURL returns a proxy func sending all the requests through the proxy at
+// proxyURL, whatever the NO_PROXY environment variable. The proxy can be an http,
+// https, socks5 or socks5h (resolving the host names) proxy.
+func ProxyURL(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q of proxy URL %q, must be http, https, socks5 or socks5h",
+			u.Scheme, proxyURL)
+	}
+
+	if len(u.Host) == 0 {
+		return nil, fmt.Errorf("proxy URL %q has no host", proxyURL)
+	}
+
+	return http.ProxyURL(u), nil
+}
+
+// newTransport returns a pooled keep-alive transport with the settings of
+// http.DefaultTransport, using proxy if it is set.
+func newTransport(tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// certRotationRoundTripper checks the rotation of the certificate files before
+// every request, as reused connections make no new handshake. The server
+// certificates are verified by crypto/tls, so the requests are sent with a new
+// transport configured with the trusted root certificates once they rotate.
+type certRotationRoundTripper struct {
+	certificates *transport.CertificateReloader
+	tlsConfig    *tls.Config
+	proxy        func(*http.Request) (*url.URL, error)
+
+	lock sync.RWMutex
+	rt   *http.Transport
+}
+
+// newCertRotationRoundTripper returns a certRotationRoundTripper sending the
+// requests with the certificates of certificates.
+func newCertRotationRoundTripper(certificates *transport.CertificateReloader, tlsConfig *tls.Config,
+	proxy func(*http.Request) (*url.URL, error)) *certRotationRoundTripper {
+	rt := &certRotationRoundTripper{
+		certificates: certificates,
+		tlsConfig:    tlsConfig,
+		proxy:        proxy,
+	}
+	rt.rt = rt.newTransport()
+
+	certificates.OnRotate(rt.rotate)
+
+	return rt
+}
+
+// newTransport returns a transport configured with the current certificates.
+func (rt *certRotationRoundTripper) newTransport() *http.Transport {
+	tlsConfig := rt.tlsConfig.Clone()
+	rt.certificates.Configure(tlsConfig)
+
+	return newTransport(tlsConfig, rt.proxy)
+}
+
+// rotate replaces the transport, the connections established with the previous
+// certificates are not reused.
+func (rt *certRotationRoundTripper) rotate() {
+	t := rt.newTransport()
+
+	rt.lock.Lock()
+	previous := rt.rt
+	rt.rt = t
+	rt.lock.Unlock()
+
+	previous.CloseIdleConnections()
+}
+
+// transport returns the current transport.
+func (rt *certRotationRoundTripper) transport() *http.Transport {
+	rt.lock.RLock()
+	defer rt.lock.RUnlock()
+
+	return rt.rt
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *certRotationRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, _ = rt.certificates.Check()
+
+	return rt.transport().RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the transport.
+func (rt *certRotationRoundTripper) CloseIdleConnections() {
+	rt.transport().CloseIdleConnections()
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+// PatchType defines the content type of a PATCH request body.
+type PatchType string
+
+// Defines the patch content types supported by the IAM API.
+const (
+	// JSONPatchType is the RFC 6902 JSON Patch content type.
+	JSONPatchType PatchType = "application/json-patch+json"
+	// MergePatchType is the RFC 7386 JSON Merge Patch content type.
+	MergePatchType PatchType = "application/merge-patch+json"
+)
@@ -0,0 +1,215 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/coding-hui/common/runtime"
+
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+// Watch attempts to begin watching the requested location. The server is expected
+// to answer with a stream of watch events, either as chunked JSON objects or as
+// server-sent events, each carrying a "type" and an "object" field.
+// Objects of Added, Modified and Deleted events are delivered as *RawObject and can
+// be decoded with RawObject.Into. Objects of Error events are delivered as error.
+func (r *Request) Watch(ctx context.Context) (watch.Interface, error) {
+	resp, err := r.stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := r.c.content.Negotiator.Decoder()
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	reqURL := resp.Request.URL.String()
+
+	var frames frameReader
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		frames = newEventStreamReader(resp.Body)
+	} else {
+		frames = newJSONFrameReader(resp.Body)
+	}
+
+	return watch.NewStreamWatcher(&watchDecoder{
+		frames:  frames,
+		body:    resp.Body,
+		decoder: decoder,
+		method:  r.verb,
+		url:     reqURL,
+	}), nil
+}
+
+// RawObject holds the undecoded object of a watch event.
+type RawObject struct {
+	// Raw is the serialized object as sent by the server.
+	Raw []byte
+
+	decoder runtime.Decoder
+}
+
+// Into decodes the object into v using the negotiated decoder.
+func (o *RawObject) Into(v interface{}) error {
+	if o.decoder == nil {
+		return json.Unmarshal(o.Raw, v)
+	}
+
+	return o.decoder.Decode(o.Raw, v)
+}
+
+// watchEvent is the wire representation of a watch event.
+type watchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// frameReader splits a watch stream into serialized events. The returned event
+// name is only set by framings which carry it out of band.
+type frameReader interface {
+	ReadFrame() (name string, data []byte, err error)
+}
+
+// watchDecoder implements watch.Decoder on top of a frameReader.
+type watchDecoder struct {
+	frames  frameReader
+	body    io.Closer
+	decoder runtime.Decoder
+	method  string
+	url     string
+}
+
+var _ watch.Decoder = &watchDecoder{}
+
+// Decode blocks until it can return the next event in the stream.
+func (d *watchDecoder) Decode() (watch.EventType, interface{}, error) {
+	name, data, err := d.frames.ReadFrame()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var event watchEvent
+	if err := d.decoder.Decode(data, &event); err != nil {
+		return "", nil, fmt.Errorf("unable to decode watch event: %w", err)
+	}
+
+	if len(event.Type) == 0 {
+		event.Type = watch.EventType(strings.ToUpper(name))
+	}
+
+	switch event.Type {
+	case watch.Added, watch.Modified, watch.Deleted:
+		return event.Type, &RawObject{Raw: event.Object, decoder: d.decoder}, nil
+	case watch.Error:
+		return event.Type, d.errorFor(event.Object), nil
+	default:
+		return "", nil, fmt.Errorf("got invalid watch event type: %q", event.Type)
+	}
+}
+
+// Close closes the underlying response body.
+func (d *watchDecoder) Close() error {
+	return d.body.Close()
+}
+
+// errorFor converts the object of an Error event into a *StatusError.
+func (d *watchDecoder) errorFor(object []byte) error {
+	statusErr := &StatusError{
+		Method: d.method,
+		URL:    d.url,
+		Body:   object,
+	}
+
+	var apiResp CommonResponse
+	if err := d.decoder.Decode(object, &apiResp); err == nil {
+		statusErr.Code = apiResp.Code
+		statusErr.Message = apiResp.Msg
+		statusErr.Reference = apiResp.Reference
+	} else {
+		statusErr.Message = string(object)
+	}
+
+	return statusErr
+}
+
+// jsonFrameReader reads consecutive JSON documents from a chunked stream.
+type jsonFrameReader struct {
+	decoder *json.Decoder
+}
+
+func newJSONFrameReader(r io.Reader) *jsonFrameReader {
+	return &jsonFrameReader{decoder: json.NewDecoder(r)}
+}
+
+// ReadFrame implements frameReader.
+func (r *jsonFrameReader) ReadFrame() (string, []byte, error) {
+	var frame json.RawMessage
+	if err := r.decoder.Decode(&frame); err != nil {
+		return "", nil, err
+	}
+
+	return "", frame, nil
+}
+
+// eventStreamReader reads server-sent events, see
+// https://html.spec.whatwg.org/multipage/server-sent-events.html.
+type eventStreamReader struct {
+	reader *bufio.Reader
+}
+
+func newEventStreamReader(r io.Reader) *eventStreamReader {
+	return &eventStreamReader{reader: bufio.NewReader(r)}
+}
+
+// ReadFrame implements frameReader. Events without data are skipped.
+func (r *eventStreamReader) ReadFrame() (string, []byte, error) {
+	var (
+		name string
+		data bytes.Buffer
+	)
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && (len(line) == 0 || err != io.EOF) {
+			return "", nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if data.Len() != 0 {
+				return name, data.Bytes(), nil
+			}
+
+			name = ""
+
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			if data.Len() != 0 {
+				data.WriteByte('\n')
+			}
+
+			data.WriteString(value)
+		}
+	}
+}
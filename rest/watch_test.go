@@ -0,0 +1,228 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coding-hui/common/runtime"
+	"github.com/coding-hui/common/scheme"
+
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "chunked json",
+			contentType: "application/json",
+			body: `{"type":"ADDED","object":{"name":"foo"}}
+{"type":"MODIFIED","object":{"name":"bar"}}
+{"type":"DELETED","object":{"name":"baz"}}
+{"type":"ERROR","object":{"code":100001,"msg":"expired"}}
+`,
+		},
+		{
+			name:        "server-sent events",
+			contentType: "text/event-stream",
+			body: `: keep alive
+
+event: added
+data: {"object":{"name":"foo"}}
+
+data: {"type":"MODIFIED",
+data: "object":{"name":"bar"}}
+
+event: deleted
+data: {"object":{"name":"baz"}}
+
+event: error
+data: {"object":{"code":100001,"msg":"expired"}}
+
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Query().Get("watch") != "true" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				fmt.Fprint(w, tc.body)
+			})
+
+			w, err := client.Get().Resource("users").Param("watch", "true").Watch(context.TODO())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer w.Stop()
+
+			expected := []struct {
+				eventType watch.EventType
+				name      string
+			}{
+				{watch.Added, "foo"},
+				{watch.Modified, "bar"},
+				{watch.Deleted, "baz"},
+			}
+
+			for _, e := range expected {
+				event, ok := <-w.ResultChan()
+				if !ok {
+					t.Fatalf("unexpected close of result channel")
+				}
+				if event.Type != e.eventType {
+					t.Errorf("expected event type %s, got %s", e.eventType, event.Type)
+				}
+
+				var obj struct {
+					Name string `json:"name"`
+				}
+				if err := event.Object.(*RawObject).Into(&obj); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if obj.Name != e.name {
+					t.Errorf("expected name %q, got %q", e.name, obj.Name)
+				}
+			}
+
+			event := <-w.ResultChan()
+			var statusErr *StatusError
+			if event.Type != watch.Error || !errors.As(event.Object.(error), &statusErr) || statusErr.Code != 100001 {
+				t.Errorf("unexpected error event: %#v", event)
+			}
+
+			if _, ok := <-w.ResultChan(); ok {
+				t.Errorf("expected result channel to be closed at the end of the stream")
+			}
+		})
+	}
+}
+
+func TestWatchStatusError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	if _, err := client.Get().Resource("users").Watch(context.TODO()); !IsForbidden(err) {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestWatchOutlivesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("watch") != "true" {
+			// a plain request takes longer than the timeout
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"code":0,"data":{}}`)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		for _, name := range []string{"foo", "bar"} {
+			fmt.Fprintf(w, `{"type":"ADDED","object":{"name":%q}}`+"\n", name)
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := RESTClientFor(&Config{
+		Host: server.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+		Timeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.Get().Resource("users").Do(context.TODO()).Error(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the request to time out, got %v", err)
+	}
+
+	w, err := client.Get().Resource("users").Param("watch", "true").Watch(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+
+	for i := 0; i < 2; i++ {
+		if event, ok := <-w.ResultChan(); !ok || event.Type != watch.Added {
+			t.Fatalf("expected an added event after the timeout, got %#v", event)
+		}
+	}
+}
+
+func TestWatchDebugBodies(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"type":"ADDED","object":{"name":"foo"}}`+"\n")
+		w.(http.Flusher).Flush()
+
+		// the stream stays open
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	var logs bytes.Buffer
+	client, err := RESTClientFor(&Config{
+		Host: server.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion: &scheme.GroupVersion{Group: "iam.api", Version: "v1"},
+			Negotiator:   runtime.NewSimpleClientNegotiator(),
+		},
+		UserAgent: "test/1.0",
+		Debug: transport.DebugConfig{
+			Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+			Level:  transport.DebugBodies,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w, err := client.Get().Resource("users").Watch(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+
+	select {
+	case event := <-w.ResultChan():
+		if event.Type != watch.Added {
+			t.Errorf("unexpected event %#v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the event to arrive while the stream is open")
+	}
+
+	if !strings.Contains(logs.String(), "requestHeaders.User-Agent=test/1.0") {
+		t.Errorf("expected the User-Agent in the logs:\n%s", logs.String())
+	}
+}
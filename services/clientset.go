@@ -5,6 +5,8 @@
 package services
 
 import (
+	"net/http"
+
 	"github.com/coding-hui/wecoding-sdk-go/rest"
 	"github.com/coding-hui/wecoding-sdk-go/services/iam"
 )
@@ -30,14 +32,35 @@ func (c *Clientset) Iam() iam.IamInterface {
 // NewForConfig creates a new Clientset for the given config.
 // If config's RateLimiter is not set and QPS and Burst are acceptable,
 // NewForConfig will generate a rate-limiter in configShallowCopy.
+// The clients share an http client, see NewForConfigAndClient.
 func NewForConfig(c *rest.Config) (*Clientset, error) {
 	configShallowCopy := *c
+	if configShallowCopy.UserAgent == "" {
+		configShallowCopy.UserAgent = rest.DefaultUserAgent()
+	}
+
+	httpClient, err := rest.HTTPClientFor(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewForConfigAndClient(&configShallowCopy, httpClient)
+}
+
+// NewForConfigAndClient creates a new Clientset for the given config and http client.
+// All the clients of the Clientset share the http client, and so its connections.
+// Note the http client provided takes precedence over the configured transport values.
+func NewForConfigAndClient(c *rest.Config, httpClient *http.Client) (*Clientset, error) {
+	configShallowCopy := *c
+	if configShallowCopy.RateLimiter == nil {
+		configShallowCopy.RateLimiter = rest.RateLimiterFor(&configShallowCopy)
+	}
 
 	var cs Clientset
 
 	var err error
 
-	cs.iam, err = iam.NewForConfig(&configShallowCopy)
+	cs.iam, err = iam.NewForConfigAndClient(&configShallowCopy, httpClient)
 	if err != nil {
 		return nil, err
 	}
@@ -48,9 +71,12 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 // NewForConfigOrDie creates a new Clientset for the given config and
 // panics if there is an error in the config.
 func NewForConfigOrDie(c *rest.Config) *Clientset {
-	var cs Clientset
-	cs.iam = iam.NewForConfigOrDie(c)
-	return &cs
+	cs, err := NewForConfig(c)
+	if err != nil {
+		panic(err)
+	}
+
+	return cs
 }
 
 // New creates a new Clientset for the given RESTClient.
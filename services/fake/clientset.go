@@ -0,0 +1,75 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"fmt"
+
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/services"
+	"github.com/coding-hui/wecoding-sdk-go/services/iam"
+	fakeapiv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/apiserver/v1/fake"
+	fakeiam "github.com/coding-hui/wecoding-sdk-go/services/iam/fake"
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+)
+
+// Clientset implements services.Interface. Meant to be embedded into a
+// struct to get a default implementation. This makes faking out just the method
+// you want to test easier.
+type Clientset struct {
+	testing.Fake
+	tracker testing.ObjectTracker
+}
+
+var _ services.Interface = &Clientset{}
+
+// NewSimpleClientset returns a clientset that will respond with the provided objects.
+// It's backed by a very simple object tracker that processes creates, updates and deletions as-is,
+// without applying any validations and/or defaults. Users are authenticated by name and password
+// and every authz request is allowed, prepend reactors to change these behaviors.
+// Supported objects are *v1.DetailUserResponse.
+func NewSimpleClientset(objects ...interface{}) *Clientset {
+	o := testing.NewObjectTracker()
+
+	for _, obj := range objects {
+		resource, err := resourceFor(obj)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := o.Add(resource, obj); err != nil {
+			panic(err)
+		}
+	}
+
+	cs := &Clientset{tracker: o}
+	cs.AddReactor("*", "users", fakeapiv1.UserReaction(o))
+	cs.AddReactor("*", "authentication", fakeapiv1.AuthenticationReaction(o))
+	cs.AddReactor("*", "*", testing.ObjectReaction(o))
+	cs.AddWatchReactor("*", testing.DefaultWatchReactor(o.Watch))
+
+	return cs
+}
+
+// Tracker returns the object tracker backing the clientset.
+func (c *Clientset) Tracker() testing.ObjectTracker {
+	return c.tracker
+}
+
+// Iam retrieves the fake IamClient.
+func (c *Clientset) Iam() iam.IamInterface {
+	return &fakeiam.FakeIam{Fake: &c.Fake}
+}
+
+// resourceFor returns the resource the object belongs to.
+func resourceFor(obj interface{}) (string, error) {
+	switch obj.(type) {
+	case *v1.DetailUserResponse:
+		return "users", nil
+	default:
+		return "", fmt.Errorf("unsupported object type %T", obj)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+	authzv1 "github.com/coding-hui/iam/pkg/api/authzserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	fakeauthzv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/authz/v1/fake"
+	coretesting "github.com/coding-hui/wecoding-sdk-go/testing"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+func newUser(id, name string) *v1.DetailUserResponse {
+	return &v1.DetailUserResponse{
+		UserBase: v1.UserBase{
+			ObjectMeta: metav1.ObjectMeta{InstanceID: id, Name: name},
+			Password:   "secret",
+		},
+	}
+}
+
+func TestUsers(t *testing.T) {
+	ctx := context.TODO()
+	cs := NewSimpleClientset(newUser("user-1", "alice"))
+	users := cs.Iam().APIV1().Users()
+
+	created, err := users.Create(ctx, &v1.CreateUserRequest{Name: "bob", Password: "pw", Alias: "Bob"}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(created.InstanceID) == 0 || created.Alias != "Bob" {
+		t.Errorf("unexpected created user: %+v", created)
+	}
+
+	if _, err := users.Create(ctx, &v1.CreateUserRequest{Name: "bob"}, metav1.CreateOptions{}); !rest.IsConflict(err) {
+		t.Errorf("expected conflict, got %v", err)
+	}
+
+	updated, err := users.Update(ctx, "user-1", &v1.UpdateUserRequest{Alias: "Alice", Email: "alice@example.com"}, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if updated.Alias != "Alice" || updated.Email != "alice@example.com" || updated.Password != "secret" {
+		t.Errorf("unexpected updated user: %+v", updated)
+	}
+
+	if err := users.Disable(ctx, "user-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	patched, err := users.Patch(ctx, "user-1", rest.JSONPatchType, []byte(`[{"op":"replace","path":"/phone","value":"123"}]`), metav1.PatchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !patched.Disabled || patched.Phone != "123" || patched.InstanceID != "user-1" {
+		t.Errorf("unexpected patched user: %+v", patched)
+	}
+
+	patched, err = users.Patch(ctx, "user-1", rest.MergePatchType, []byte(`{"alias":"A","phone":null}`), metav1.PatchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if patched.Alias != "A" || patched.Phone != "" {
+		t.Errorf("unexpected merge patched user: %+v", patched)
+	}
+
+	list, err := users.List(ctx, metav1.ListOptions{FieldSelector: "name=bob"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if list.TotalCount != 1 || len(list.Items) != 1 || list.Items[0].Name != "bob" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	if err := users.Delete(ctx, "user-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := users.Get(ctx, "user-1", metav1.GetOptions{}); !rest.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	verbs := []string{"create", "create", "update", "disable", "patch", "patch", "list", "delete", "get"}
+
+	actions := cs.Actions()
+	if len(actions) != len(verbs) {
+		t.Fatalf("expected %d actions, got %d", len(verbs), len(actions))
+	}
+
+	for i, verb := range verbs {
+		if !actions[i].Matches(verb, "users") {
+			t.Errorf("expected action %d to be %s users, got %s %s", i, verb, actions[i].GetVerb(), actions[i].GetResource())
+		}
+	}
+}
+
+func TestUsersListAll(t *testing.T) {
+	var objects []interface{}
+	for i := 0; i < 25; i++ {
+		objects = append(objects, newUser(fmt.Sprintf("user-%02d", i), fmt.Sprintf("user%02d", i)))
+	}
+
+	cs := NewSimpleClientset(objects...)
+
+	limit := int64(10)
+
+	users, err := cs.Iam().APIV1().Users().ListAll(context.TODO(), metav1.ListOptions{Limit: &limit})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(users) != 25 || users[24].InstanceID != "user-24" {
+		t.Errorf("unexpected users: %d", len(users))
+	}
+
+	if got := len(cs.Actions()); got != 3 {
+		t.Errorf("expected 3 list actions, got %d", got)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	ctx := context.TODO()
+	cs := NewSimpleClientset(newUser("user-1", "alice"))
+	auth := cs.Iam().APIV1().Authentication()
+
+	if _, err := auth.Login(ctx, "alice", "wrong"); !rest.IsUnauthorized(err) {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+
+	login, err := auth.Login(ctx, "alice", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	refreshed, err := auth.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := auth.RefreshToken(ctx, login.RefreshToken); !rest.IsUnauthorized(err) {
+		t.Errorf("expected used refresh token to be rejected, got %v", err)
+	}
+
+	user, err := auth.UserInfo(ctx, refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if user.InstanceID != "user-1" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+}
+
+func TestAuthz(t *testing.T) {
+	cs := NewSimpleClientset()
+	authz := cs.Iam().AuthzV1().Authz()
+
+	resp, err := authz.Authorize(context.TODO(), &authzv1.Request{Subject: "alice", Action: "get", Resource: "users"})
+	if err != nil || !resp.Allowed {
+		t.Errorf("expected allowed by default, got %+v, %v", resp, err)
+	}
+
+	cs.PrependReactor("create", "authz", fakeauthzv1.AuthorizeReaction(func(request *authzv1.Request) *authzv1.Response {
+		return &authzv1.Response{Allowed: request.Action == "get", Denied: request.Action != "get"}
+	}))
+
+	resp, err = authz.Authorize(context.TODO(), &authzv1.Request{Subject: "alice", Action: "delete", Resource: "users"})
+	if err != nil || !resp.Denied {
+		t.Errorf("expected denied, got %+v, %v", resp, err)
+	}
+}
+
+func TestReactorError(t *testing.T) {
+	cs := NewSimpleClientset(newUser("user-1", "alice"))
+	cs.PrependReactor("get", "users", func(coretesting.Action) (bool, interface{}, error) {
+		return true, nil, &rest.StatusError{StatusCode: 503}
+	})
+
+	if _, err := cs.Iam().APIV1().Users().Get(context.TODO(), "user-1", metav1.GetOptions{}); !rest.IsServiceUnavailable(err) {
+		t.Errorf("expected injected error, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.TODO()
+	cs := NewSimpleClientset()
+	users := cs.Iam().APIV1().Users()
+
+	w, err := users.Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+
+	created, err := users.Create(ctx, &v1.CreateUserRequest{Name: "bob"}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case event := <-w.ResultChan():
+		user, ok := event.Object.(*v1.DetailUserResponse)
+		if event.Type != watch.Added || !ok || user.InstanceID != created.InstanceID {
+			t.Errorf("unexpected event: %#v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestWatchNotHandled(t *testing.T) {
+	cs := NewSimpleClientset()
+	cs.WatchReactionChain = nil
+	cs.PrependWatchReactor("users", func(coretesting.Action) (bool, watch.Interface, error) {
+		return false, nil, nil
+	})
+
+	w, err := cs.Iam().APIV1().Users().Watch(context.TODO(), metav1.ListOptions{})
+	if err == nil || w != nil {
+		t.Errorf("expected an error without a watch, got %v, %v", w, err)
+	}
+}
@@ -0,0 +1,8 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package fake has the fake implementation of services.Interface. It is backed
+// by an in-memory object tracker, records every call as an action and lets
+// tests inject reactions, e.g. errors, before the default ones.
+package fake
@@ -5,6 +5,8 @@
 package v1
 
 import (
+	"net/http"
+
 	"github.com/coding-hui/common/runtime"
 	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
 
@@ -34,11 +36,31 @@ func (c *APIV1Client) Authentication() AuthenticationInterface {
 }
 
 // NewForConfig creates a new APIV1Client for the given config.
+// If config has a Username and Password, the client logs in with them
+// and authenticates with the tokens it obtains, see ConfigurePasswordAuth.
 func NewForConfig(c *rest.Config) (*APIV1Client, error) {
 	config := *c
 	setConfigDefaults(&config)
 
-	client, err := rest.RESTClientFor(&config)
+	httpClient, err := rest.HTTPClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewForConfigAndClient(&config, httpClient)
+}
+
+// NewForConfigAndClient creates a new APIV1Client for the given config and http client.
+// Note the http client provided takes precedence over the configured transport values.
+func NewForConfigAndClient(c *rest.Config, h *http.Client) (*APIV1Client, error) {
+	config := *c
+	if err := ConfigurePasswordAuth(&config, h); err != nil {
+		return nil, err
+	}
+
+	setConfigDefaults(&config)
+
+	client, err := rest.RESTClientForConfigAndClient(&config, h)
 	if err != nil {
 		return nil, err
 	}
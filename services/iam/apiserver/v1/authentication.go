@@ -62,7 +62,7 @@ func (a *authentication) Login(ctx context.Context, username, password string) (
 
 func (a *authentication) RefreshToken(ctx context.Context, refreshToken ...string) (*v1.RefreshTokenResponse, error) {
 	request := a.client.Get().Suffix("/auth/refresh-token")
-	if len(refreshToken) >= 0 {
+	if len(refreshToken) != 0 {
 		request.SetHeader("RefreshToken", refreshToken[0])
 	}
 	result := &v1.RefreshTokenResponse{}
@@ -72,7 +72,7 @@ func (a *authentication) RefreshToken(ctx context.Context, refreshToken ...strin
 
 func (a *authentication) UserInfo(ctx context.Context, accessToken ...string) (*v1.DetailUserResponse, error) {
 	request := a.client.Get().Suffix("/auth/user-info")
-	if len(accessToken) >= 0 {
+	if len(accessToken) != 0 {
 		request.SetHeader("Authorization", fmt.Sprintf("Bearer %s", accessToken[0]))
 	}
 	result := &v1.DetailUserResponse{}
@@ -0,0 +1,35 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	apiv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/apiserver/v1"
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+)
+
+// FakeAPIV1 implements apiv1.APIV1Interface on top of a testing.Fake.
+type FakeAPIV1 struct {
+	*testing.Fake
+}
+
+var _ apiv1.APIV1Interface = &FakeAPIV1{}
+
+// Users returns a fake UserInterface.
+func (c *FakeAPIV1) Users() apiv1.UserInterface {
+	return &FakeUsers{c}
+}
+
+// Authentication returns a fake AuthenticationInterface.
+func (c *FakeAPIV1) Authentication() apiv1.AuthenticationInterface {
+	return &FakeAuthentication{c}
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeAPIV1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
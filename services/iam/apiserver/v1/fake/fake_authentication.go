@@ -0,0 +1,68 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+)
+
+// authenticationResource is the resource of the actions recorded by FakeAuthentication.
+const authenticationResource = "authentication"
+
+// FakeAuthentication implements AuthenticationInterface. Its actions are
+// generic actions with the "login", "refresh" and "userinfo" verbs.
+type FakeAuthentication struct {
+	Fake *FakeAPIV1
+}
+
+// Login authenticates the user with a username and a password.
+func (c *FakeAuthentication) Login(ctx context.Context, username, password string) (*v1.AuthenticateResponse, error) {
+	return c.Authenticate(ctx, v1.AuthenticateRequest{
+		Username: username,
+		Password: password,
+	})
+}
+
+// Authenticate authenticates the user described by loginReq.
+func (c *FakeAuthentication) Authenticate(_ context.Context, loginReq v1.AuthenticateRequest) (*v1.AuthenticateResponse, error) {
+	obj, err := c.Fake.Invokes(testing.NewGenericAction("login", authenticationResource, &loginReq), &v1.AuthenticateResponse{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*v1.AuthenticateResponse), err
+}
+
+// RefreshToken exchanges a refresh token for a new access token.
+func (c *FakeAuthentication) RefreshToken(_ context.Context, refreshToken ...string) (*v1.RefreshTokenResponse, error) {
+	obj, err := c.Fake.Invokes(testing.NewGenericAction("refresh", authenticationResource, firstOrEmpty(refreshToken)), &v1.RefreshTokenResponse{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*v1.RefreshTokenResponse), err
+}
+
+// UserInfo returns the user the access token was issued to.
+func (c *FakeAuthentication) UserInfo(_ context.Context, accessToken ...string) (*v1.DetailUserResponse, error) {
+	obj, err := c.Fake.Invokes(testing.NewGenericAction("userinfo", authenticationResource, firstOrEmpty(accessToken)), &v1.DetailUserResponse{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*v1.DetailUserResponse), err
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+// usersResource is the resource of the actions recorded by FakeUsers.
+const usersResource = "users"
+
+// FakeUsers implements UserInterface.
+type FakeUsers struct {
+	Fake *FakeAPIV1
+}
+
+// Get takes the instance id of the user, and returns the corresponding user object, and an error if there is any.
+func (c *FakeUsers) Get(_ context.Context, id string, _ metav1.GetOptions) (*v1.DetailUserResponse, error) {
+	obj, err := c.Fake.Invokes(testing.NewGetAction(usersResource, id), &v1.DetailUserResponse{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*v1.DetailUserResponse), err
+}
+
+// Create takes the representation of a user and creates it.
+// Returns the server's representation of the user, and an error, if there is any.
+func (c *FakeUsers) Create(_ context.Context, user *v1.CreateUserRequest, _ metav1.CreateOptions) (*v1.CreateUserResponse, error) {
+	obj, err := c.Fake.Invokes(testing.NewCreateAction(usersResource, user), &v1.DetailUserResponse{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return &v1.CreateUserResponse{UserBase: obj.(*v1.DetailUserResponse).UserBase}, err
+}
+
+// Update takes the representation of a user and updates it.
+// Returns the server's representation of the user, and an error, if there is any.
+func (c *FakeUsers) Update(_ context.Context, id string, user *v1.UpdateUserRequest, _ metav1.UpdateOptions) (*v1.UpdateUserResponse, error) {
+	obj, err := c.Fake.Invokes(testing.NewUpdateAction(usersResource, id, user), &v1.DetailUserResponse{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return &v1.UpdateUserResponse{UserBase: obj.(*v1.DetailUserResponse).UserBase}, err
+}
+
+// Delete takes the instance id of the user and deletes it. Returns an error if one occurs.
+func (c *FakeUsers) Delete(_ context.Context, id string, _ metav1.DeleteOptions) error {
+	_, err := c.Fake.Invokes(testing.NewDeleteAction(usersResource, id), &v1.DetailUserResponse{})
+
+	return err
+}
+
+// List takes label and field selectors, and returns the list of users that match those selectors.
+func (c *FakeUsers) List(_ context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+	obj, err := c.Fake.Invokes(testing.NewListAction(usersResource, opts), &v1.UserList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*v1.UserList), err
+}
+
+// Watch returns a watch.Interface that watches the requested users.
+func (c *FakeUsers) Watch(_ context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.Fake.InvokesWatch(testing.NewWatchAction(usersResource, opts))
+}
+
+// Patch applies the patch and returns the patched user.
+func (c *FakeUsers) Patch(_ context.Context, id string, pt rest.PatchType, data []byte, _ metav1.PatchOptions) (*v1.DetailUserResponse, error) {
+	obj, err := c.Fake.Invokes(testing.NewPatchAction(usersResource, id, pt, data), &v1.DetailUserResponse{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*v1.DetailUserResponse), err
+}
+
+// Disable takes the instance id of the user and disables it.
+func (c *FakeUsers) Disable(_ context.Context, id string) error {
+	_, err := c.Fake.Invokes(testing.NewGenericAction("disable", usersResource, id), nil)
+
+	return err
+}
+
+// Enable takes the instance id of the user and enables it.
+func (c *FakeUsers) Enable(_ context.Context, id string) error {
+	_, err := c.Fake.Invokes(testing.NewGenericAction("enable", usersResource, id), nil)
+
+	return err
+}
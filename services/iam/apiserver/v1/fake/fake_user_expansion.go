@@ -0,0 +1,25 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+	"iter"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	apiv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/apiserver/v1"
+)
+
+// ListAll fetches every user page by page.
+func (c *FakeUsers) ListAll(ctx context.Context, opts metav1.ListOptions) ([]*v1.DetailUserResponse, error) {
+	return apiv1.NewUserListPager(c).List(ctx, opts)
+}
+
+// All returns an iterator over every user.
+func (c *FakeUsers) All(ctx context.Context, opts metav1.ListOptions) iter.Seq2[*v1.DetailUserResponse, error] {
+	return apiv1.NewUserListPager(c).All(ctx, opts)
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/coding-hui/common/fields"
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	listerv1 "github.com/coding-hui/wecoding-sdk-go/listers/iam/apiserver/v1"
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+)
+
+// tokenExpiresIn is the lifetime in seconds reported for the issued access tokens.
+const tokenExpiresIn = 3600
+
+// UserReaction returns a ReactionFunc giving the users of the tracker the
+// semantics of the IAM API server: users are created and updated from their
+// request bodies, listed with field selectors and offset/limit pagination,
+// and enabled or disabled. Get, delete, patch and watch are left to
+// testing.ObjectReaction.
+func UserReaction(tracker testing.ObjectTracker) testing.ReactionFunc {
+	return func(action testing.Action) (bool, interface{}, error) {
+		switch action := action.(type) {
+		case testing.CreateActionImpl:
+			req, ok := action.Object.(*v1.CreateUserRequest)
+			if !ok {
+				return false, nil, nil
+			}
+
+			user, err := createUser(tracker, req)
+			if err != nil {
+				return true, nil, err
+			}
+
+			return true, user, nil
+		case testing.UpdateActionImpl:
+			req, ok := action.Object.(*v1.UpdateUserRequest)
+			if !ok {
+				return false, nil, nil
+			}
+
+			user, err := updateUser(tracker, action.Name, func(user *v1.DetailUserResponse) {
+				user.Alias = req.Alias
+				if len(req.Email) != 0 {
+					user.Email = req.Email
+				}
+
+				if len(req.Phone) != 0 {
+					user.Phone = req.Phone
+				}
+
+				if len(req.Password) != 0 {
+					user.Password = req.Password
+				}
+			})
+			if err != nil {
+				return true, nil, err
+			}
+
+			return true, user, nil
+		case testing.ListActionImpl:
+			list, err := listUsers(tracker, action.ListOptions)
+			if err != nil {
+				return true, nil, err
+			}
+
+			return true, list, nil
+		case testing.GenericActionImpl:
+			if action.Verb != "enable" && action.Verb != "disable" {
+				return false, nil, nil
+			}
+
+			id, _ := action.Value.(string)
+			_, err := updateUser(tracker, id, func(user *v1.DetailUserResponse) {
+				user.Disabled = action.Verb == "disable"
+			})
+
+			return true, nil, err
+		default:
+			return false, nil, nil
+		}
+	}
+}
+
+// createUser adds the user described by req to the tracker.
+func createUser(tracker testing.ObjectTracker, req *v1.CreateUserRequest) (*v1.DetailUserResponse, error) {
+	user := &v1.DetailUserResponse{
+		UserBase: v1.UserBase{
+			ObjectMeta:    metav1.ObjectMeta{Name: req.Name},
+			Alias:         req.Alias,
+			Password:      req.Password,
+			Email:         req.Email,
+			Phone:         req.Phone,
+			UserType:      req.UserType,
+			Avatar:        req.Avatar,
+			DepartmentIds: req.DepartmentIds,
+		},
+	}
+
+	if err := tracker.Create(usersResource, user); err != nil {
+		return nil, err
+	}
+
+	return getUser(tracker, user.InstanceID)
+}
+
+// updateUser applies mutate to the tracked user.
+func updateUser(tracker testing.ObjectTracker, id string, mutate func(user *v1.DetailUserResponse)) (*v1.DetailUserResponse, error) {
+	user, err := getUser(tracker, id)
+	if err != nil {
+		return nil, err
+	}
+
+	mutate(user)
+
+	if err := tracker.Update(usersResource, user); err != nil {
+		return nil, err
+	}
+
+	return getUser(tracker, id)
+}
+
+// getUser returns the tracked user.
+func getUser(tracker testing.ObjectTracker, id string) (*v1.DetailUserResponse, error) {
+	obj, err := tracker.Get(usersResource, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user, ok := obj.(*v1.DetailUserResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T for users", obj)
+	}
+
+	return user, nil
+}
+
+// listUsers returns the page of tracked users selected by opts.
+func listUsers(tracker testing.ObjectTracker, opts metav1.ListOptions) (*v1.UserList, error) {
+	selector := fields.Everything()
+
+	if len(opts.FieldSelector) != 0 {
+		var err error
+
+		selector, err = fields.ParseSelector(opts.FieldSelector)
+		if err != nil {
+			return nil, rest.NewBadRequest(err.Error())
+		}
+	}
+
+	objs, err := tracker.List(usersResource)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*v1.DetailUserResponse
+
+	for _, obj := range objs {
+		if user, ok := obj.(*v1.DetailUserResponse); ok && selector.Matches(listerv1.UserFields(user)) {
+			users = append(users, user)
+		}
+	}
+
+	list := &v1.UserList{ListMeta: metav1.ListMeta{TotalCount: int64(len(users))}}
+
+	offset, limit := pageWindow(opts)
+	if offset < int64(len(users)) {
+		users = users[offset:]
+		if limit > 0 && limit < int64(len(users)) {
+			users = users[:limit]
+		}
+
+		list.Items = users
+	}
+
+	return list, nil
+}
+
+// pageWindow returns the offset and limit selected by opts, Offset and Limit
+// take precedence over Page and PageSize. A zero limit means no limit.
+func pageWindow(opts metav1.ListOptions) (offset, limit int64) {
+	if opts.Offset != nil || opts.Limit != nil {
+		if opts.Offset != nil {
+			offset = max(*opts.Offset, 0)
+		}
+
+		if opts.Limit != nil {
+			limit = max(*opts.Limit, 0)
+		}
+
+		return offset, limit
+	}
+
+	if opts.Page != nil && opts.PageSize != nil && *opts.PageSize > 0 {
+		return max(*opts.Page-1, 0) * *opts.PageSize, *opts.PageSize
+	}
+
+	return 0, 0
+}
+
+// AuthenticationReaction returns a ReactionFunc authenticating the users of the
+// tracker by name and password. It issues opaque access and refresh tokens
+// which are only known to the returned reaction.
+func AuthenticationReaction(tracker testing.ObjectTracker) testing.ReactionFunc {
+	s := &sessions{
+		accessTokens:  make(map[string]string),
+		refreshTokens: make(map[string]string),
+	}
+
+	return func(action testing.Action) (bool, interface{}, error) {
+		generic, ok := action.(testing.GenericActionImpl)
+		if !ok || generic.Resource != authenticationResource {
+			return false, nil, nil
+		}
+
+		switch generic.Verb {
+		case "login":
+			req, ok := generic.Value.(*v1.AuthenticateRequest)
+			if !ok {
+				return false, nil, nil
+			}
+
+			resp, err := s.login(tracker, req)
+			if err != nil {
+				return true, nil, err
+			}
+
+			return true, resp, nil
+		case "refresh":
+			token, _ := generic.Value.(string)
+			resp, err := s.refresh(tracker, token)
+			if err != nil {
+				return true, nil, err
+			}
+
+			return true, resp, nil
+		case "userinfo":
+			token, _ := generic.Value.(string)
+			user, err := s.userInfo(tracker, token)
+			if err != nil {
+				return true, nil, err
+			}
+
+			return true, user, nil
+		default:
+			return false, nil, nil
+		}
+	}
+}
+
+// sessions keeps the tokens issued by an AuthenticationReaction.
+type sessions struct {
+	lock sync.Mutex
+	// accessTokens and refreshTokens map tokens to user instance ids.
+	accessTokens  map[string]string
+	refreshTokens map[string]string
+	lastToken     int
+}
+
+// login checks the credentials of req and issues tokens for the user.
+func (s *sessions) login(tracker testing.ObjectTracker, req *v1.AuthenticateRequest) (*v1.AuthenticateResponse, error) {
+	objs, err := tracker.List(usersResource)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objs {
+		user, ok := obj.(*v1.DetailUserResponse)
+		if !ok || user.Name != req.Username || user.Password != req.Password {
+			continue
+		}
+
+		if user.Disabled {
+			return nil, rest.NewUnauthorized(fmt.Sprintf("user %q is disabled", user.Name))
+		}
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		accessToken, refreshToken := s.issue(user.InstanceID)
+
+		return &v1.AuthenticateResponse{
+			User:         &user.UserBase,
+			AccessToken:  accessToken,
+			TokenType:    "Bearer",
+			RefreshToken: refreshToken,
+			ExpiresIn:    tokenExpiresIn,
+		}, nil
+	}
+
+	return nil, rest.NewUnauthorized("invalid username or password")
+}
+
+// refresh exchanges a refresh token for new tokens.
+func (s *sessions) refresh(tracker testing.ObjectTracker, refreshToken string) (*v1.RefreshTokenResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	id, ok := s.refreshTokens[refreshToken]
+	if !ok {
+		return nil, rest.NewUnauthorized("invalid refresh token")
+	}
+
+	if _, err := getUser(tracker, id); err != nil {
+		return nil, rest.NewUnauthorized("invalid refresh token")
+	}
+
+	delete(s.refreshTokens, refreshToken)
+	accessToken, refreshToken := s.issue(id)
+
+	return &v1.RefreshTokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// userInfo returns the user an access token was issued to.
+func (s *sessions) userInfo(tracker testing.ObjectTracker, accessToken string) (*v1.DetailUserResponse, error) {
+	s.lock.Lock()
+	id, ok := s.accessTokens[accessToken]
+	s.lock.Unlock()
+
+	if !ok {
+		return nil, rest.NewUnauthorized("invalid access token")
+	}
+
+	return getUser(tracker, id)
+}
+
+// issue returns new tokens for the user. It must be called with the lock held.
+func (s *sessions) issue(id string) (accessToken, refreshToken string) {
+	s.lastToken++
+	accessToken = fmt.Sprintf("access-token-%d", s.lastToken)
+	refreshToken = fmt.Sprintf("refresh-token-%d", s.lastToken)
+
+	s.accessTokens[accessToken] = id
+	s.refreshTokens[refreshToken] = id
+
+	return accessToken, refreshToken
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+)
+
+// DefaultExpiryDelta is how long before its expiry an access token is refreshed.
+const DefaultExpiryDelta = 30 * time.Second
+
+// DefaultLoginTimeout bounds the login or refresh obtaining a token pair, whatever
+// the deadlines of the requests waiting for it.
+const DefaultLoginTimeout = 30 * time.Second
+
+// passwordTokenSource implements transport.ResettableTokenSource by exchanging
+// a username and password for an access and refresh token pair.
+type passwordTokenSource struct {
+	auth     AuthenticationInterface
+	username string
+	password string
+	now      func() time.Time
+
+	lock         sync.Mutex
+	accessToken  string
+	refreshToken string
+	// lifetime is the lifetime of the access tokens given at login, zero if unknown.
+	lifetime time.Duration
+	obtained time.Time
+	// expiry is when the access token expires, zero if unknown.
+	expiry time.Time
+	// fetching is the token pair being obtained, nil if none is.
+	fetching *tokenFetch
+}
+
+// tokenFetch is the obtaining of a token pair, shared by the callers waiting for it.
+type tokenFetch struct {
+	// done is closed once the token pair is set or err is.
+	done chan struct{}
+	err  error
+}
+
+var _ transport.ContextTokenSource = &passwordTokenSource{}
+
+var _ transport.ResettableTokenSource = &passwordTokenSource{}
+
+// NewPasswordTokenSource returns a token source logging in with username and
+// password through auth. The access token is cached and refreshed with the
+// refresh token DefaultExpiryDelta before it expires, or when it is reset after
+// the server rejected it. It logs in again when the refresh fails. The returned
+// source is safe for concurrent use: a single login or refresh is made at a time,
+// without blocking the callers which have a valid token or give up waiting.
+func NewPasswordTokenSource(auth AuthenticationInterface, username, password string) transport.ResettableTokenSource {
+	return &passwordTokenSource{
+		auth:     auth,
+		username: username,
+		password: password,
+		now:      time.Now,
+	}
+}
+
+// Token implements transport.TokenSource.
+func (ts *passwordTokenSource) Token() (string, error) {
+	return ts.TokenContext(context.Background())
+}
+
+// TokenContext implements transport.ContextTokenSource.
+func (ts *passwordTokenSource) TokenContext(ctx context.Context) (string, error) {
+	ts.lock.Lock()
+
+	if ts.valid(ts.now()) {
+		defer ts.lock.Unlock()
+
+		return ts.accessToken, nil
+	}
+
+	fetch := ts.fetching
+	if fetch == nil {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		ts.fetching = fetch
+
+		// the callers waiting for the token pair don't depend on the first one
+		go ts.fetch(context.WithoutCancel(ctx), fetch)
+	}
+
+	ts.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-fetch.done:
+	}
+
+	if fetch.err != nil {
+		return "", fetch.err
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	return ts.accessToken, nil
+}
+
+// ResetTokenOlderThan implements transport.ResettableTokenSource.
+func (ts *passwordTokenSource) ResetTokenOlderThan(t time.Time) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if ts.obtained.Before(t) {
+		ts.expiry = ts.obtained
+	}
+}
+
+// valid returns whether the access token can be used at now. It must be called
+// with the lock held.
+func (ts *passwordTokenSource) valid(now time.Time) bool {
+	if len(ts.accessToken) == 0 {
+		return false
+	}
+
+	if ts.expiry.IsZero() {
+		return true
+	}
+
+	return now.Add(DefaultExpiryDelta).Before(ts.expiry)
+}
+
+// fetch obtains a new token pair, with the refresh token if there is one and by
+// logging in otherwise, and completes fetch. It is called without the lock held.
+func (ts *passwordTokenSource) fetch(ctx context.Context, fetch *tokenFetch) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultLoginTimeout)
+	defer cancel()
+
+	ts.lock.Lock()
+	refreshToken := ts.refreshToken
+	ts.lock.Unlock()
+
+	now := ts.now()
+
+	var err error
+	if len(refreshToken) == 0 || ts.refresh(ctx, refreshToken, now) != nil {
+		err = ts.login(ctx, now)
+	}
+
+	ts.lock.Lock()
+	ts.fetching = nil
+	ts.lock.Unlock()
+
+	fetch.err = err
+	close(fetch.done)
+}
+
+// login exchanges the username and password for a new token pair.
+func (ts *passwordTokenSource) login(ctx context.Context, now time.Time) error {
+	resp, err := ts.auth.Login(ctx, ts.username, ts.password)
+	if err != nil {
+		return fmt.Errorf("failed to login as %q: %w", ts.username, err)
+	}
+
+	if len(resp.AccessToken) == 0 {
+		return fmt.Errorf("failed to login as %q: no access token returned", ts.username)
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.lifetime = time.Duration(resp.ExpiresIn) * time.Second
+	ts.set(resp.AccessToken, resp.RefreshToken, now)
+
+	return nil
+}
+
+// refresh exchanges refreshToken for a new token pair. The refresh token is
+// discarded when the exchange fails.
+func (ts *passwordTokenSource) refresh(ctx context.Context, refreshToken string, now time.Time) error {
+	resp, err := ts.auth.RefreshToken(ctx, refreshToken)
+	if err == nil && len(resp.AccessToken) == 0 {
+		err = fmt.Errorf("no access token returned")
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if err != nil {
+		ts.refreshToken = ""
+		return err
+	}
+
+	if len(resp.RefreshToken) != 0 {
+		refreshToken = resp.RefreshToken
+	}
+
+	ts.set(resp.AccessToken, refreshToken, now)
+
+	return nil
+}
+
+// set caches a new token pair obtained at now. It must be called with the lock held.
+func (ts *passwordTokenSource) set(accessToken, refreshToken string, now time.Time) {
+	ts.accessToken = accessToken
+	ts.refreshToken = refreshToken
+	ts.obtained = now
+	ts.expiry = time.Time{}
+
+	if ts.lifetime > 0 {
+		ts.expiry = now.Add(ts.lifetime)
+	}
+}
+
+// ConfigurePasswordAuth replaces the Username and Password of config, if any, with
+// a token source logging in with them, see NewPasswordTokenSource. The clients
+// built from config then share the same tokens. The token source sends its
+// requests with httpClient, so they share its connections; if nil, an http
+// client is built for config.
+func ConfigurePasswordAuth(config *rest.Config, httpClient *http.Client) error {
+	if len(config.Username) == 0 || config.TokenSource != nil || config.CredentialProvider != nil {
+		return nil
+	}
+
+	anonymous := *config
+	anonymous.Username = ""
+	anonymous.Password = ""
+	// the tokens are obtained for the user logging in, not the impersonated one
+	anonymous.Impersonate = transport.ImpersonationConfig{}
+
+	var client *APIV1Client
+
+	var err error
+
+	if httpClient != nil {
+		client, err = NewForConfigAndClient(&anonymous, httpClient)
+	} else {
+		client, err = NewForConfig(&anonymous)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	config.TokenSource = NewPasswordTokenSource(client.Authentication(), config.Username, config.Password)
+	config.Username = ""
+	config.Password = ""
+
+	return nil
+}
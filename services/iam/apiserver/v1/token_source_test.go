@@ -0,0 +1,222 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+)
+
+// authServer is a fake server issuing tokens for the user foo.
+type authServer struct {
+	lock      sync.Mutex
+	logins    int
+	refreshes int
+	issued    int
+	access    string
+	refresh   string
+	expiresIn int
+}
+
+func (s *authServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var data interface{}
+
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/login"):
+		var login v1.AuthenticateRequest
+		if err := json.NewDecoder(req.Body).Decode(&login); err != nil || login.Username != "foo" || login.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		s.logins++
+		data = s.issue()
+	case strings.HasSuffix(req.URL.Path, "/auth/refresh-token"):
+		if req.Header.Get("RefreshToken") != s.refresh {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		s.refreshes++
+		data = s.issue()
+	default:
+		if req.Header.Get("Authorization") != "Bearer "+s.access {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		data = v1.DetailUserResponse{}
+	}
+
+	json.NewEncoder(w).Encode(rest.CommonResponse{Success: true, Data: data})
+}
+
+// issue returns a new token pair. It must be called with the lock held.
+func (s *authServer) issue() v1.AuthenticateResponse {
+	s.issued++
+	s.access = fmt.Sprintf("access-%d", s.issued)
+	s.refresh = fmt.Sprintf("refresh-%d", s.issued)
+
+	return v1.AuthenticateResponse{AccessToken: s.access, RefreshToken: s.refresh, ExpiresIn: s.expiresIn}
+}
+
+// revoke invalidates the access token without invalidating the refresh token.
+func (s *authServer) revoke() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.access = "revoked"
+}
+
+func TestPasswordAuth(t *testing.T) {
+	server := &authServer{}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewForConfig(&rest.Config{Host: httpServer.URL, Username: "foo", Password: "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Users().Get(context.TODO(), "user-1", metav1.GetOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// the server rejects the access token, it is refreshed and the request retried
+	server.revoke()
+
+	if _, err := client.Users().Get(context.TODO(), "user-1", metav1.GetOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if server.logins != 1 || server.refreshes != 1 {
+		t.Errorf("expected 1 login and 1 refresh, got %d and %d", server.logins, server.refreshes)
+	}
+}
+
+func TestPasswordTokenSourceExpiry(t *testing.T) {
+	server := &authServer{expiresIn: 3600}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewForConfig(&rest.Config{Host: httpServer.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	ts := NewPasswordTokenSource(client.Authentication(), "foo", "secret").(*passwordTokenSource)
+	ts.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{name: "login", want: "access-1"},
+		{name: "cached", elapsed: time.Hour - DefaultExpiryDelta - time.Second, want: "access-1"},
+		{name: "refreshed before expiry", elapsed: time.Second, want: "access-2"},
+		{name: "refreshed after expiry", elapsed: 2 * time.Hour, want: "access-3"},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(tt.elapsed)
+
+		if token, err := ts.Token(); err != nil || token != tt.want {
+			t.Errorf("%s: expected %q, got %q, error %v", tt.name, tt.want, token, err)
+		}
+	}
+
+	// a rejected refresh token falls back to a login
+	server.lock.Lock()
+	server.refresh = "revoked"
+	server.lock.Unlock()
+	now = now.Add(2 * time.Hour)
+
+	if token, err := ts.Token(); err != nil || token != "access-4" {
+		t.Errorf("expected %q, got %q, error %v", "access-4", token, err)
+	}
+
+	if server.logins != 2 {
+		t.Errorf("expected 2 logins, got %d", server.logins)
+	}
+}
+
+func TestPasswordTokenSourceContext(t *testing.T) {
+	release := make(chan struct{})
+	server := &authServer{}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+		server.ServeHTTP(w, req)
+	}))
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(func() { close(release) })
+
+	client, err := NewForConfig(&rest.Config{Host: httpServer.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ts := NewPasswordTokenSource(client.Authentication(), "foo", "secret").(*passwordTokenSource)
+
+	// the callers give up waiting for a hung login at their deadline
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		_, err := ts.TokenContext(ctx)
+		cancel()
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the deadline to be exceeded, got %v", err)
+		}
+	}
+}
+
+func TestConfigurePasswordAuthSharesClient(t *testing.T) {
+	server := &authServer{}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	var requests []string
+	httpClient := &http.Client{
+		Transport: transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req.URL.Path)
+
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	}
+
+	client, err := NewForConfigAndClient(&rest.Config{Host: httpServer.URL, Username: "foo", Password: "secret"}, httpClient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.Users().Get(context.TODO(), "user-1", metav1.GetOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 || !strings.HasSuffix(requests[0], "/login") {
+		t.Errorf("expected the login and the request to use the http client, got %v", requests)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
 
 	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
 )
 
 // UsersGetter has a method to return a UserInterface.
@@ -26,6 +27,8 @@ type UserInterface interface {
 	Update(ctx context.Context, id string, user *v1.UpdateUserRequest, opts metav1.UpdateOptions) (*v1.UpdateUserResponse, error)
 	Delete(ctx context.Context, id string, opts metav1.DeleteOptions) error
 	List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, id string, pt rest.PatchType, data []byte, opts metav1.PatchOptions) (*v1.DetailUserResponse, error)
 	Disable(ctx context.Context, id string) error
 	Enable(ctx context.Context, id string) error
 	UserExpansion
@@ -107,6 +110,36 @@ func (c *users) List(ctx context.Context, opts metav1.ListOptions) (result *v1.U
 	return
 }
 
+// Watch returns a watch.Interface that watches the requested users.
+// Objects of Added, Modified and Deleted events are *v1.DetailUserResponse.
+func (c *users) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	w, err := c.client.Get().
+		Resource("users").
+		VersionedParams(opts).
+		Param("watch", "true").
+		Watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch.Filter(w, decodeWatchEvent(func() interface{} { return &v1.DetailUserResponse{} })), nil
+}
+
+// Patch applies the patch document data of type pt to the user and returns the
+// server's representation of the patched user, and an error, if there is any.
+func (c *users) Patch(ctx context.Context, id string, pt rest.PatchType, data []byte, opts metav1.PatchOptions) (result *v1.DetailUserResponse, err error) {
+	result = &v1.DetailUserResponse{}
+	err = c.client.Patch(pt).
+		Resource("users").
+		VersionedParams(opts).
+		Name(id).
+		Body(data).
+		Do(ctx).
+		Into(result)
+
+	return
+}
+
 // Disable disable user
 func (c *users) Disable(ctx context.Context, id string) error {
 	return c.client.Get().
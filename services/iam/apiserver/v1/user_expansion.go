@@ -4,24 +4,43 @@
 
 package v1
 
+import (
+	"context"
+	"iter"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+)
+
 // The UserExpansion interface allows manually adding extra methods to the UserInterface.
-type UserExpansion interface { // PatchStatus modifies the status of an existing node. It returns the copy
-	// of the node that the server returns, or an error.
-	// PatchStatus(ctx context.Context, nodeName string, data []byte) (*v1.Node, error)
+type UserExpansion interface {
+	// ListAll fetches every user page by page, starting at the Offset of opts.
+	ListAll(ctx context.Context, opts metav1.ListOptions) ([]*v1.DetailUserResponse, error)
+	// All returns an iterator over every user, fetching pages as the iteration advances.
+	All(ctx context.Context, opts metav1.ListOptions) iter.Seq2[*v1.DetailUserResponse, error]
+}
+
+// NewUserListPager returns a pager over the users listed by c. Use it instead of
+// ListAll or All to tune the page size or to prefetch pages in parallel.
+func NewUserListPager(c UserInterface) *rest.ListPager[*v1.DetailUserResponse] {
+	return rest.NewListPager(func(ctx context.Context, opts metav1.ListOptions) ([]*v1.DetailUserResponse, int64, error) {
+		list, err := c.List(ctx, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return list.Items, list.TotalCount, nil
+	})
+}
+
+// ListAll fetches every user page by page.
+func (c *users) ListAll(ctx context.Context, opts metav1.ListOptions) ([]*v1.DetailUserResponse, error) {
+	return NewUserListPager(c).List(ctx, opts)
 }
 
-/*
-// PatchStatus modifies the status of an existing node. It returns the copy of
-// the node that the server returns, or an error.
-func (c *nodes) PatchStatus(ctx context.Context, nodeName string, data []byte) (*v1.Node, error) {
-	result := &v1.Node{}
-	err := c.client.Patch(types.StrategicMergePatchType).
-		Resource("nodes").
-		Name(nodeName).
-		SubResource("status").
-		Body(data).
-		Do(ctx).
-		Into(result)
-	return result, err
+// All returns an iterator over every user.
+func (c *users) All(ctx context.Context, opts metav1.ListOptions) iter.Seq2[*v1.DetailUserResponse, error] {
+	return NewUserListPager(c).All(ctx, opts)
 }
-*/
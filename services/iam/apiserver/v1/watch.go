@@ -0,0 +1,29 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+// decodeWatchEvent returns a watch.FilterFunc which decodes the raw object of
+// each event into the object returned by newObject. Objects which can not be
+// decoded are turned into Error events.
+func decodeWatchEvent(newObject func() interface{}) watch.FilterFunc {
+	return func(in watch.Event) (watch.Event, bool) {
+		raw, ok := in.Object.(*rest.RawObject)
+		if !ok {
+			return in, true
+		}
+
+		obj := newObject()
+		if err := raw.Into(obj); err != nil {
+			return watch.Event{Type: watch.Error, Object: err}, true
+		}
+
+		return watch.Event{Type: in.Type, Object: obj}, true
+	}
+}
@@ -5,6 +5,8 @@
 package v1
 
 import (
+	"net/http"
+
 	"github.com/coding-hui/common/runtime"
 	v1 "github.com/coding-hui/iam/pkg/api/authzserver/v1"
 
@@ -32,7 +34,21 @@ func NewForConfig(c *rest.Config) (*AuthzV1Client, error) {
 	config := *c
 	setConfigDefaults(&config)
 
-	client, err := rest.RESTClientFor(&config)
+	httpClient, err := rest.HTTPClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewForConfigAndClient(&config, httpClient)
+}
+
+// NewForConfigAndClient creates a new AuthzV1Client for the given config and http client.
+// Note the http client provided takes precedence over the configured transport values.
+func NewForConfigAndClient(c *rest.Config, h *http.Client) (*AuthzV1Client, error) {
+	config := *c
+	setConfigDefaults(&config)
+
+	client, err := rest.RESTClientForConfigAndClient(&config, h)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+
+	v1 "github.com/coding-hui/iam/pkg/api/authzserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+)
+
+// authzResource is the resource of the actions recorded by FakeAuthz.
+const authzResource = "authz"
+
+// FakeAuthz implements AuthzInterface. Requests are recorded as create actions
+// of the authz resource and are allowed unless a reactor decides otherwise.
+type FakeAuthz struct {
+	Fake *FakeAuthzV1
+}
+
+// Authorize returns the decision of the reactors for request.
+func (c *FakeAuthz) Authorize(_ context.Context, request *v1.Request) (*v1.Response, error) {
+	obj, err := c.Fake.Invokes(testing.NewCreateAction(authzResource, request), &v1.Response{Allowed: true})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*v1.Response), err
+}
+
+// AuthorizeReaction returns a ReactionFunc answering authz requests with the
+// decision of decide. Register it with AddReactor("create", "authz", ...).
+func AuthorizeReaction(decide func(request *v1.Request) *v1.Response) testing.ReactionFunc {
+	return func(action testing.Action) (bool, interface{}, error) {
+		create, ok := action.(testing.CreateAction)
+		if !ok {
+			return false, nil, nil
+		}
+
+		request, ok := create.GetObject().(*v1.Request)
+		if !ok {
+			return false, nil, nil
+		}
+
+		return true, decide(request), nil
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	authzv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/authz/v1"
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+)
+
+// FakeAuthzV1 implements authzv1.AuthzV1Interface on top of a testing.Fake.
+type FakeAuthzV1 struct {
+	*testing.Fake
+}
+
+var _ authzv1.AuthzV1Interface = &FakeAuthzV1{}
+
+// Authz returns a fake AuthzInterface.
+func (c *FakeAuthzV1) Authz() authzv1.AuthzInterface {
+	return &FakeAuthz{c}
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeAuthzV1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
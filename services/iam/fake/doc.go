@@ -0,0 +1,6 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package fake has the fake client of the iam service.
+package fake
@@ -0,0 +1,31 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"github.com/coding-hui/wecoding-sdk-go/services/iam"
+	apiv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/apiserver/v1"
+	fakeapiv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/apiserver/v1/fake"
+	authzv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/authz/v1"
+	fakeauthzv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/authz/v1/fake"
+	"github.com/coding-hui/wecoding-sdk-go/testing"
+)
+
+// FakeIam implements iam.IamInterface on top of a testing.Fake.
+type FakeIam struct {
+	*testing.Fake
+}
+
+var _ iam.IamInterface = &FakeIam{}
+
+// APIV1 retrieves the fake APIV1Client.
+func (c *FakeIam) APIV1() apiv1.APIV1Interface {
+	return &fakeapiv1.FakeAPIV1{Fake: c.Fake}
+}
+
+// AuthzV1 retrieves the fake AuthzV1Client.
+func (c *FakeIam) AuthzV1() authzv1.AuthzV1Interface {
+	return &fakeauthzv1.FakeAuthzV1{Fake: c.Fake}
+}
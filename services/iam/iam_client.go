@@ -5,6 +5,8 @@
 package iam
 
 import (
+	"net/http"
+
 	"github.com/coding-hui/wecoding-sdk-go/rest"
 	apiv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/apiserver/v1"
 	authzv1 "github.com/coding-hui/wecoding-sdk-go/services/iam/authz/v1"
@@ -34,20 +36,49 @@ func (c *IamClient) AuthzV1() authzv1.AuthzV1Interface {
 	return c.authzV1
 }
 
-// NewForConfig creates a new IamV1Client for the given config.
+// NewForConfig creates a new IamClient for the given config.
+// If config's RateLimiter is not set and QPS and Burst are acceptable,
+// NewForConfig will generate a rate-limiter shared by the group clients.
+// A Username and Password are exchanged for tokens shared by the group
+// clients as well, see apiv1.ConfigurePasswordAuth.
+// The group clients share an http client, see NewForConfigAndClient.
 func NewForConfig(c *rest.Config) (*IamClient, error) {
 	configShallowCopy := *c
+	if configShallowCopy.UserAgent == "" {
+		configShallowCopy.UserAgent = rest.DefaultUserAgent()
+	}
+
+	httpClient, err := rest.HTTPClientFor(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewForConfigAndClient(&configShallowCopy, httpClient)
+}
+
+// NewForConfigAndClient creates a new IamClient for the given config and http client.
+// The group clients share the http client, and so its connections.
+// Note the http client provided takes precedence over the configured transport values.
+func NewForConfigAndClient(c *rest.Config, httpClient *http.Client) (*IamClient, error) {
+	configShallowCopy := *c
+	if configShallowCopy.RateLimiter == nil {
+		configShallowCopy.RateLimiter = rest.RateLimiterFor(&configShallowCopy)
+	}
+
+	if err := apiv1.ConfigurePasswordAuth(&configShallowCopy, httpClient); err != nil {
+		return nil, err
+	}
 
 	var ic IamClient
 
 	var err error
 
-	ic.apiV1, err = apiv1.NewForConfig(&configShallowCopy)
+	ic.apiV1, err = apiv1.NewForConfigAndClient(&configShallowCopy, httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	ic.authzV1, err = authzv1.NewForConfig(&configShallowCopy)
+	ic.authzV1, err = authzv1.NewForConfigAndClient(&configShallowCopy, httpClient)
 	if err != nil {
 		return nil, err
 	}
@@ -58,11 +89,12 @@ func NewForConfig(c *rest.Config) (*IamClient, error) {
 // NewForConfigOrDie creates a new IamClient for the given config and
 // panics if there is an error in the config.
 func NewForConfigOrDie(c *rest.Config) *IamClient {
-	var ic IamClient
-	ic.apiV1 = apiv1.NewForConfigOrDie(c)
-	ic.authzV1 = authzv1.NewForConfigOrDie(c)
+	ic, err := NewForConfig(c)
+	if err != nil {
+		panic(err)
+	}
 
-	return &ic
+	return ic
 }
 
 // New creates a new IamClient for the given RESTClient.
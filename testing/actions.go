@@ -0,0 +1,271 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	metav1 "github.com/coding-hui/common/meta/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+)
+
+// NewGetAction returns an action getting the resource named name.
+func NewGetAction(resource, name string) GetActionImpl {
+	return GetActionImpl{
+		ActionImpl: ActionImpl{Verb: "get", Resource: resource},
+		Name:       name,
+	}
+}
+
+// NewListAction returns an action listing the resource.
+func NewListAction(resource string, opts metav1.ListOptions) ListActionImpl {
+	return ListActionImpl{
+		ActionImpl:  ActionImpl{Verb: "list", Resource: resource},
+		ListOptions: opts,
+	}
+}
+
+// NewCreateAction returns an action creating object.
+func NewCreateAction(resource string, object interface{}) CreateActionImpl {
+	return CreateActionImpl{
+		ActionImpl: ActionImpl{Verb: "create", Resource: resource},
+		Object:     object,
+	}
+}
+
+// NewUpdateAction returns an action updating the resource named name with object.
+func NewUpdateAction(resource, name string, object interface{}) UpdateActionImpl {
+	return UpdateActionImpl{
+		ActionImpl: ActionImpl{Verb: "update", Resource: resource},
+		Name:       name,
+		Object:     object,
+	}
+}
+
+// NewDeleteAction returns an action deleting the resource named name.
+func NewDeleteAction(resource, name string) DeleteActionImpl {
+	return DeleteActionImpl{
+		ActionImpl: ActionImpl{Verb: "delete", Resource: resource},
+		Name:       name,
+	}
+}
+
+// NewPatchAction returns an action patching the resource named name.
+func NewPatchAction(resource, name string, pt rest.PatchType, patch []byte) PatchActionImpl {
+	return PatchActionImpl{
+		ActionImpl: ActionImpl{Verb: "patch", Resource: resource},
+		Name:       name,
+		PatchType:  pt,
+		Patch:      patch,
+	}
+}
+
+// NewWatchAction returns an action watching the resource.
+func NewWatchAction(resource string, opts metav1.ListOptions) WatchActionImpl {
+	return WatchActionImpl{
+		ActionImpl:   ActionImpl{Verb: "watch", Resource: resource},
+		WatchOptions: opts,
+	}
+}
+
+// NewGenericAction returns an action for a verb which is not part of the
+// standard ones, value carries its arguments.
+func NewGenericAction(verb, resource string, value interface{}) GenericActionImpl {
+	return GenericActionImpl{
+		ActionImpl: ActionImpl{Verb: verb, Resource: resource},
+		Value:      value,
+	}
+}
+
+// Action is a call recorded by a Fake.
+type Action interface {
+	GetVerb() string
+	GetResource() string
+	GetSubresource() string
+	Matches(verb, resource string) bool
+}
+
+// GetAction is an Action getting a single object.
+type GetAction interface {
+	Action
+	GetName() string
+}
+
+// ListAction is an Action listing objects.
+type ListAction interface {
+	Action
+	GetListOptions() metav1.ListOptions
+}
+
+// CreateAction is an Action creating an object.
+type CreateAction interface {
+	Action
+	GetObject() interface{}
+}
+
+// UpdateAction is an Action updating a single object.
+type UpdateAction interface {
+	Action
+	GetName() string
+	GetObject() interface{}
+}
+
+// DeleteAction is an Action deleting a single object.
+type DeleteAction interface {
+	Action
+	GetName() string
+}
+
+// PatchAction is an Action patching a single object.
+type PatchAction interface {
+	Action
+	GetName() string
+	GetPatchType() rest.PatchType
+	GetPatch() []byte
+}
+
+// WatchAction is an Action watching objects.
+type WatchAction interface {
+	Action
+	GetWatchOptions() metav1.ListOptions
+}
+
+// GenericAction is an Action for a non standard verb.
+type GenericAction interface {
+	Action
+	GetValue() interface{}
+}
+
+// ActionImpl implements the common methods of every Action.
+type ActionImpl struct {
+	Verb        string
+	Resource    string
+	Subresource string
+}
+
+// GetVerb implements Action.
+func (a ActionImpl) GetVerb() string {
+	return a.Verb
+}
+
+// GetResource implements Action.
+func (a ActionImpl) GetResource() string {
+	return a.Resource
+}
+
+// GetSubresource implements Action.
+func (a ActionImpl) GetSubresource() string {
+	return a.Subresource
+}
+
+// Matches returns true if the action has the verb and the resource.
+func (a ActionImpl) Matches(verb, resource string) bool {
+	return a.Verb == verb && a.Resource == resource
+}
+
+// GetActionImpl implements GetAction.
+type GetActionImpl struct {
+	ActionImpl
+	Name string
+}
+
+// GetName implements GetAction.
+func (a GetActionImpl) GetName() string {
+	return a.Name
+}
+
+// ListActionImpl implements ListAction.
+type ListActionImpl struct {
+	ActionImpl
+	ListOptions metav1.ListOptions
+}
+
+// GetListOptions implements ListAction.
+func (a ListActionImpl) GetListOptions() metav1.ListOptions {
+	return a.ListOptions
+}
+
+// CreateActionImpl implements CreateAction.
+type CreateActionImpl struct {
+	ActionImpl
+	Object interface{}
+}
+
+// GetObject implements CreateAction.
+func (a CreateActionImpl) GetObject() interface{} {
+	return a.Object
+}
+
+// UpdateActionImpl implements UpdateAction.
+type UpdateActionImpl struct {
+	ActionImpl
+	Name   string
+	Object interface{}
+}
+
+// GetName implements UpdateAction.
+func (a UpdateActionImpl) GetName() string {
+	return a.Name
+}
+
+// GetObject implements UpdateAction.
+func (a UpdateActionImpl) GetObject() interface{} {
+	return a.Object
+}
+
+// DeleteActionImpl implements DeleteAction.
+type DeleteActionImpl struct {
+	ActionImpl
+	Name string
+}
+
+// GetName implements DeleteAction.
+func (a DeleteActionImpl) GetName() string {
+	return a.Name
+}
+
+// PatchActionImpl implements PatchAction.
+type PatchActionImpl struct {
+	ActionImpl
+	Name      string
+	PatchType rest.PatchType
+	Patch     []byte
+}
+
+// GetName implements PatchAction.
+func (a PatchActionImpl) GetName() string {
+	return a.Name
+}
+
+// GetPatchType implements PatchAction.
+func (a PatchActionImpl) GetPatchType() rest.PatchType {
+	return a.PatchType
+}
+
+// GetPatch implements PatchAction.
+func (a PatchActionImpl) GetPatch() []byte {
+	return a.Patch
+}
+
+// WatchActionImpl implements WatchAction.
+type WatchActionImpl struct {
+	ActionImpl
+	WatchOptions metav1.ListOptions
+}
+
+// GetWatchOptions implements WatchAction.
+func (a WatchActionImpl) GetWatchOptions() metav1.ListOptions {
+	return a.WatchOptions
+}
+
+// GenericActionImpl implements GenericAction.
+type GenericActionImpl struct {
+	ActionImpl
+	Value interface{}
+}
+
+// GetValue implements GenericAction.
+func (a GenericActionImpl) GetValue() interface{} {
+	return a.Value
+}
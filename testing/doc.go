@@ -0,0 +1,7 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package testing provides the building blocks of the fake clients: the actions
+// recorded by a Fake, the reactors answering them and an in-memory object tracker.
+package testing
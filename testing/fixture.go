@@ -0,0 +1,419 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+// watchBufferSize is the number of events a tracker watch buffers. A watch whose
+// buffer is full is stopped, see tracker.notify.
+const watchBufferSize = 100
+
+// ObjectTracker keeps track of objects. It is intended to be used to
+// fake calls to a server by returning objects based on their resource
+// and instance id. Objects must implement metav1.ObjectMetaAccessor.
+type ObjectTracker interface {
+	// Add adds an object to the tracker, replacing any object with the same
+	// instance id. It is meant to seed the tracker with fixtures.
+	Add(resource string, obj interface{}) error
+	// Get retrieves the object by its resource and instance id.
+	Get(resource, id string) (interface{}, error)
+	// List retrieves all the objects of the resource ordered by instance id.
+	List(resource string) ([]interface{}, error)
+	// Create adds an object to the tracker. An instance id is generated when
+	// obj has none. Names must be unique within a resource.
+	Create(resource string, obj interface{}) error
+	// Update updates an existing object in the tracker.
+	Update(resource string, obj interface{}) error
+	// Delete deletes an existing object from the tracker.
+	Delete(resource, id string) error
+	// Watch watches the changes made to the objects of the resource.
+	Watch(resource string) (watch.Interface, error)
+}
+
+// tracker implements ObjectTracker.
+type tracker struct {
+	lock     sync.RWMutex
+	objects  map[string]map[string]interface{}
+	watchers map[string][]*watch.FakeWatcher
+	lastID   uint64
+}
+
+var _ ObjectTracker = &tracker{}
+
+// NewObjectTracker returns an empty ObjectTracker.
+func NewObjectTracker() ObjectTracker {
+	return &tracker{
+		objects:  make(map[string]map[string]interface{}),
+		watchers: make(map[string][]*watch.FakeWatcher),
+	}
+}
+
+// Add implements ObjectTracker.
+func (t *tracker) Add(resource string, obj interface{}) error {
+	meta, err := objectMeta(obj)
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(meta.GetInstanceID()) == 0 {
+		t.generateID(resource, meta)
+	}
+
+	_, exists := t.objects[resource][meta.GetInstanceID()]
+	if err := t.store(resource, meta.GetInstanceID(), obj); err != nil {
+		return err
+	}
+
+	if exists {
+		t.notify(resource, watch.Modified, obj)
+	} else {
+		t.notify(resource, watch.Added, obj)
+	}
+
+	return nil
+}
+
+// Get implements ObjectTracker.
+func (t *tracker) Get(resource, id string) (interface{}, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	obj, exists := t.objects[resource][id]
+	if !exists {
+		return nil, rest.NewNotFound(resource, id)
+	}
+
+	return deepCopy(obj)
+}
+
+// List implements ObjectTracker.
+func (t *tracker) List(resource string) ([]interface{}, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	ids := make([]string, 0, len(t.objects[resource]))
+	for id := range t.objects[resource] {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	list := make([]interface{}, 0, len(ids))
+
+	for _, id := range ids {
+		obj, err := deepCopy(t.objects[resource][id])
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, obj)
+	}
+
+	return list, nil
+}
+
+// Create implements ObjectTracker.
+func (t *tracker) Create(resource string, obj interface{}) error {
+	meta, err := objectMeta(obj)
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, exists := t.objects[resource][meta.GetInstanceID()]; exists {
+		return rest.NewAlreadyExists(resource, meta.GetInstanceID())
+	}
+
+	if len(meta.GetName()) != 0 {
+		for _, existing := range t.objects[resource] {
+			if existingMeta, _ := objectMeta(existing); existingMeta.GetName() == meta.GetName() {
+				return rest.NewAlreadyExists(resource, meta.GetName())
+			}
+		}
+	}
+
+	if len(meta.GetInstanceID()) == 0 {
+		t.generateID(resource, meta)
+	}
+
+	now := time.Now()
+	meta.SetCreatedAt(now)
+	meta.SetUpdatedAt(now)
+
+	if err := t.store(resource, meta.GetInstanceID(), obj); err != nil {
+		return err
+	}
+
+	t.notify(resource, watch.Added, obj)
+
+	return nil
+}
+
+// Update implements ObjectTracker.
+func (t *tracker) Update(resource string, obj interface{}) error {
+	meta, err := objectMeta(obj)
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	existing, exists := t.objects[resource][meta.GetInstanceID()]
+	if !exists {
+		return rest.NewNotFound(resource, meta.GetInstanceID())
+	}
+
+	existingMeta, _ := objectMeta(existing)
+	meta.SetID(existingMeta.GetID())
+	meta.SetCreatedAt(existingMeta.GetCreatedAt())
+	meta.SetUpdatedAt(time.Now())
+
+	if err := t.store(resource, meta.GetInstanceID(), obj); err != nil {
+		return err
+	}
+
+	t.notify(resource, watch.Modified, obj)
+
+	return nil
+}
+
+// Delete implements ObjectTracker.
+func (t *tracker) Delete(resource, id string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	obj, exists := t.objects[resource][id]
+	if !exists {
+		return rest.NewNotFound(resource, id)
+	}
+
+	delete(t.objects[resource], id)
+	t.notify(resource, watch.Deleted, obj)
+
+	return nil
+}
+
+// Watch implements ObjectTracker.
+func (t *tracker) Watch(resource string) (watch.Interface, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	w := watch.NewFakeWithChanSize(watchBufferSize)
+	t.watchers[resource] = append(t.watchers[resource], w)
+
+	return w, nil
+}
+
+// generateID assigns a new instance id to an object. It must be called with
+// the lock held.
+func (t *tracker) generateID(resource string, meta metav1.Object) {
+	t.lastID++
+	meta.SetID(t.lastID)
+	meta.SetInstanceID(fmt.Sprintf("%s-%06d", resource, t.lastID))
+}
+
+// store saves a copy of obj. It must be called with the lock held.
+func (t *tracker) store(resource, id string, obj interface{}) error {
+	obj, err := deepCopy(obj)
+	if err != nil {
+		return err
+	}
+
+	if t.objects[resource] == nil {
+		t.objects[resource] = make(map[string]interface{})
+	}
+
+	t.objects[resource][id] = obj
+
+	return nil
+}
+
+// notify sends an event to the watchers of the resource, dropping the stopped
+// ones. It must be called with the lock held, so it never blocks: a watcher
+// which is not read and has no room left for the event is stopped, as a server
+// would close a slow watch, and its reader sees the end of the watch after the
+// buffered events.
+func (t *tracker) notify(resource string, eventType watch.EventType, obj interface{}) {
+	watchers := t.watchers[resource][:0]
+
+	for _, w := range t.watchers[resource] {
+		if w.IsStopped() {
+			continue
+		}
+
+		if obj, err := deepCopy(obj); err == nil && !w.TryAction(eventType, obj) {
+			w.Stop()
+			continue
+		}
+
+		watchers = append(watchers, w)
+	}
+
+	t.watchers[resource] = watchers
+}
+
+// objectMeta returns the metadata of obj.
+func objectMeta(obj interface{}) (metav1.Object, error) {
+	accessor, ok := obj.(metav1.ObjectMetaAccessor)
+	if !ok {
+		return nil, fmt.Errorf("object does not implement the ObjectMetaAccessor interface: %T", obj)
+	}
+
+	return accessor.GetObjectMeta(), nil
+}
+
+// deepCopy returns a copy of the object pointed to by obj. Fields hidden from
+// JSON are not copied, except for the metadata ID.
+func deepCopy(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAs(obj, data)
+}
+
+// decodeAs decodes data into a new object of the same type as obj, keeping the
+// metadata ID of obj.
+func decodeAs(obj interface{}, data []byte) (interface{}, error) {
+	typ := reflect.TypeOf(obj)
+	if typ.Kind() != reflect.Pointer {
+		return nil, fmt.Errorf("object must be a pointer: %T", obj)
+	}
+
+	out := reflect.New(typ.Elem()).Interface()
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+
+	if meta, err := objectMeta(obj); err == nil {
+		outMeta, _ := objectMeta(out)
+		outMeta.SetID(meta.GetID())
+	}
+
+	return out, nil
+}
+
+// ObjectReaction returns a ReactionFunc that applies the get, list, create,
+// update, delete and patch actions to the tracker. Create and update actions
+// whose object does not implement metav1.ObjectMetaAccessor, e.g. request
+// bodies, and other actions are left to the next reactors.
+func ObjectReaction(tracker ObjectTracker) ReactionFunc {
+	return func(action Action) (bool, interface{}, error) {
+		switch action := action.(type) {
+		case GetActionImpl:
+			obj, err := tracker.Get(action.Resource, action.Name)
+			return true, obj, err
+		case ListActionImpl:
+			list, err := tracker.List(action.Resource)
+			return true, list, err
+		case CreateActionImpl:
+			meta, err := objectMeta(action.Object)
+			if err != nil {
+				return false, nil, nil
+			}
+
+			if err := tracker.Create(action.Resource, action.Object); err != nil {
+				return true, nil, err
+			}
+
+			obj, err := tracker.Get(action.Resource, meta.GetInstanceID())
+
+			return true, obj, err
+		case UpdateActionImpl:
+			meta, err := objectMeta(action.Object)
+			if err != nil {
+				return false, nil, nil
+			}
+
+			if len(action.Name) != 0 {
+				meta.SetInstanceID(action.Name)
+			}
+
+			if err := tracker.Update(action.Resource, action.Object); err != nil {
+				return true, nil, err
+			}
+
+			obj, err := tracker.Get(action.Resource, meta.GetInstanceID())
+
+			return true, obj, err
+		case DeleteActionImpl:
+			return true, nil, tracker.Delete(action.Resource, action.Name)
+		case PatchActionImpl:
+			obj, err := patchObject(tracker, action)
+			return true, obj, err
+		default:
+			return false, nil, nil
+		}
+	}
+}
+
+// patchObject applies a patch action to the tracked object and returns the result.
+func patchObject(tracker ObjectTracker, action PatchActionImpl) (interface{}, error) {
+	obj, err := tracker.Get(action.Resource, action.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	switch action.PatchType {
+	case rest.MergePatchType:
+		data, err = applyMergePatch(data, action.Patch)
+	case rest.JSONPatchType:
+		data, err = applyJSONPatch(data, action.Patch)
+	default:
+		err = fmt.Errorf("patch type %q is not supported", action.PatchType)
+	}
+
+	if err != nil {
+		return nil, rest.NewBadRequest(err.Error())
+	}
+
+	patched, err := decodeAs(obj, data)
+	if err != nil {
+		return nil, rest.NewBadRequest(err.Error())
+	}
+
+	// the instance id identifies the object, it can't be patched
+	meta, _ := objectMeta(patched)
+	meta.SetInstanceID(action.Name)
+
+	if err := tracker.Update(action.Resource, patched); err != nil {
+		return nil, err
+	}
+
+	return tracker.Get(action.Resource, action.Name)
+}
+
+// DefaultWatchReactor returns a WatchReactionFunc that watches the resource
+// of the action through watchFunc.
+func DefaultWatchReactor(watchFunc func(resource string) (watch.Interface, error)) WatchReactionFunc {
+	return func(action Action) (bool, watch.Interface, error) {
+		w, err := watchFunc(action.GetResource())
+		return true, w, err
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+)
+
+type testObject struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+func TestTrackerUnreadWatch(t *testing.T) {
+	tracker := NewObjectTracker()
+
+	w, err := tracker.Watch("users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// more changes than the watch buffers, which nobody reads
+		for i := 0; i < 2*watchBufferSize; i++ {
+			obj := &testObject{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("user-%d", i)}}
+			if err := tracker.Create("users", obj); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+
+		w.Stop()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the changes not to block on the unread watch")
+	}
+
+	// the slow watch is stopped after the buffered events
+	events := 0
+	for range w.ResultChan() {
+		events++
+	}
+
+	if events != watchBufferSize {
+		t.Errorf("expected %d buffered events, got %d", watchBufferSize, events)
+	}
+}
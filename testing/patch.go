@@ -0,0 +1,335 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// applyMergePatch applies a RFC 7386 JSON Merge Patch to doc.
+func applyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	return json.Marshal(mergeValue(target, p))
+}
+
+// mergeValue merges patch into target following RFC 7386.
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+
+		targetObj[k] = mergeValue(targetObj[k], v)
+	}
+
+	return targetObj
+}
+
+// jsonPatchOperation is a single operation of a RFC 6902 JSON Patch.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch applies a RFC 6902 JSON Patch to doc.
+func applyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid json patch: %w", err)
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		var err error
+
+		root, err = applyOperation(root, op)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+// applyOperation applies op to root and returns the new root.
+func applyOperation(root interface{}, op jsonPatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return addValue(root, path, value)
+		case "replace":
+			if root, _, err = removeValue(root, path); err != nil {
+				return nil, err
+			}
+
+			return addValue(root, path, value)
+		default:
+			current, err := getValue(root, path)
+			if err != nil {
+				return nil, err
+			}
+
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("test failed")
+			}
+
+			return root, nil
+		}
+	case "remove":
+		root, _, err = removeValue(root, path)
+		return root, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if op.Op == "move" {
+			root, value, err = removeValue(root, from)
+		} else {
+			value, err = getValue(root, from)
+			if err == nil {
+				value, err = copyValue(value)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return addValue(root, path, value)
+	default:
+		return nil, fmt.Errorf("unsupported operation")
+	}
+}
+
+// parsePointer splits a RFC 6901 JSON Pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if len(pointer) == 0 {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex parses the reference token of an array element, last is the
+// largest acceptable index.
+func arrayIndex(token string, last int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > last {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	return i, nil
+}
+
+// getValue returns the value at path.
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+
+			doc = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse %q", token)
+		}
+	}
+
+	return doc, nil
+}
+
+// addValue adds value at path and returns the new document.
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, path := path[0], path[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 0 {
+			node[token] = value
+			return node, nil
+		}
+
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+
+		child, err := addValue(child, path, value)
+		if err != nil {
+			return nil, err
+		}
+
+		node[token] = child
+
+		return node, nil
+	case []interface{}:
+		if len(path) == 0 {
+			if token == "-" {
+				return append(node, value), nil
+			}
+
+			i, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+
+			return slices.Insert(node, i, value), nil
+		}
+
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := addValue(node[i], path, value)
+		if err != nil {
+			return nil, err
+		}
+
+		node[i] = child
+
+		return node, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse %q", token)
+	}
+}
+
+// removeValue removes the value at path and returns the new document and the
+// removed value.
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	token, path := path[0], path[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", token)
+		}
+
+		if len(path) == 0 {
+			delete(node, token)
+			return node, child, nil
+		}
+
+		child, removed, err := removeValue(child, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		node[token] = child
+
+		return node, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(path) == 0 {
+			removed := node[i]
+			return slices.Delete(node, i, i+1), removed, nil
+		}
+
+		child, removed, err := removeValue(node[i], path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		node[i] = child
+
+		return node, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("cannot traverse %q", token)
+	}
+}
+
+// copyValue returns a deep copy of a decoded JSON value.
+func copyValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "add and replace",
+			doc:   `{"a":1,"b":{"c":[1,2]}}`,
+			patch: `[{"op":"add","path":"/b/c/1","value":5},{"op":"replace","path":"/a","value":"x"}]`,
+			want:  `{"a":"x","b":{"c":[1,5,2]}}`,
+		},
+		{
+			name:  "append remove move copy",
+			doc:   `{"a":[1],"b":{"c":2},"d~/e":3}`,
+			patch: `[{"op":"add","path":"/a/-","value":2},{"op":"remove","path":"/a/0"},{"op":"move","from":"/b/c","path":"/m"},{"op":"copy","from":"/d~0~1e","path":"/n"}]`,
+			want:  `{"a":[2],"b":{},"d~/e":3,"m":2,"n":3}`,
+		},
+		{
+			name:  "test passes",
+			doc:   `{"a":{"b":null}}`,
+			patch: `[{"op":"test","path":"/a/b","value":null}]`,
+			want:  `{"a":{"b":null}}`,
+		},
+		{
+			name:    "test fails",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"test","path":"/a","value":2}]`,
+			wantErr: true,
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"replace","path":"/b","value":2}]`,
+			wantErr: true,
+		},
+		{
+			name:    "index out of range",
+			doc:     `{"a":[1]}`,
+			patch:   `[{"op":"add","path":"/a/3","value":2}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertJSONEqual(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	got, err := applyMergePatch([]byte(`{"a":1,"b":{"c":1,"d":2},"e":[1]}`), []byte(`{"a":null,"b":{"c":3},"e":[2]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertJSONEqual(t, `{"b":{"c":3,"d":2},"e":[2]}`, got)
+}
+
+func assertJSONEqual(t *testing.T, want string, got []byte) {
+	t.Helper()
+
+	var wantValue, gotValue interface{}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid expected json: %v", err)
+	}
+
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+
+	if !reflect.DeepEqual(wantValue, gotValue) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
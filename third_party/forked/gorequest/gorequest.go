@@ -1398,7 +1398,7 @@ func (s *SuperAgent) MakeRequest() (*http.Request, error) {
 	}
 
 	if s.ctx != nil {
-		req.WithContext(s.ctx)
+		req = req.WithContext(s.ctx)
 	}
 
 	for k, vals := range s.Header {
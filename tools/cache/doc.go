@@ -0,0 +1,10 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package cache is a client-side caching mechanism. It is useful for
+// reducing the number of server calls you'd otherwise need to make.
+// Reflector watches a server and updates a Store. A SharedIndexInformer
+// keeps an indexed Store in sync and dispatches add/update/delete
+// notifications to registered ResourceEventHandlers.
+package cache // import "github.com/coding-hui/wecoding-sdk-go/tools/cache"
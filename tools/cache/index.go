@@ -0,0 +1,381 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+)
+
+// Indexer extends Store with multiple indices and restricts each
+// accumulator to simply hold the current object (and be empty after
+// Delete).
+type Indexer interface {
+	Store
+	// Index returns the stored objects whose set of indexed values
+	// intersects the set of indexed values of the given object, for
+	// the named index
+	Index(indexName string, obj interface{}) ([]interface{}, error)
+	// IndexKeys returns the storage keys of the stored objects whose
+	// set of indexed values for the named index includes the given
+	// indexed value
+	IndexKeys(indexName, indexedValue string) ([]string, error)
+	// ListIndexFuncValues returns all the indexed values of the given index
+	ListIndexFuncValues(indexName string) []string
+	// ByIndex returns the stored objects whose set of indexed values
+	// for the named index includes the given indexed value
+	ByIndex(indexName, indexedValue string) ([]interface{}, error)
+	// GetIndexers return the indexers
+	GetIndexers() Indexers
+	// AddIndexers adds more indexers to this store. If you call this after you already have data
+	// in the store, the results are undefined.
+	AddIndexers(newIndexers Indexers) error
+}
+
+// IndexFunc knows how to compute the set of indexed values for an object.
+type IndexFunc func(obj interface{}) ([]string, error)
+
+// Indexers maps a name to an IndexFunc.
+type Indexers map[string]IndexFunc
+
+// Index maps the indexed value to a set of keys in the store that match on that value.
+type Index map[string]map[string]struct{}
+
+// NameIndex is the name of the index built by MetaNameIndexFunc.
+const NameIndex = "name"
+
+// MetaNameIndexFunc is an IndexFunc which indexes API objects by name.
+func MetaNameIndexFunc(obj interface{}) ([]string, error) {
+	accessor, ok := obj.(metav1.ObjectMetaAccessor)
+	if !ok {
+		return nil, fmt.Errorf("object does not implement the ObjectMetaAccessor interface")
+	}
+
+	return []string{accessor.GetObjectMeta().GetName()}, nil
+}
+
+// NewIndexer returns an Indexer implemented simply with a map and a lock.
+func NewIndexer(keyFunc KeyFunc, indexers Indexers) Indexer {
+	if indexers == nil {
+		indexers = Indexers{}
+	}
+
+	return &threadSafeStore{
+		items:    map[string]interface{}{},
+		keyFunc:  keyFunc,
+		indexers: indexers,
+		indices:  map[string]Index{},
+	}
+}
+
+// threadSafeStore implements Indexer with a map guarded by a lock.
+type threadSafeStore struct {
+	lock     sync.RWMutex
+	items    map[string]interface{}
+	keyFunc  KeyFunc
+	indexers Indexers
+	indices  map[string]Index
+}
+
+var _ Indexer = &threadSafeStore{}
+
+// Add implements Store.
+func (c *threadSafeStore) Add(obj interface{}) error {
+	return c.Update(obj)
+}
+
+// Update implements Store.
+func (c *threadSafeStore) Update(obj interface{}) error {
+	key, err := c.keyFunc(obj)
+	if err != nil {
+		return KeyError{obj, err}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	oldObject := c.items[key]
+	c.items[key] = obj
+
+	return c.updateIndices(oldObject, obj, key)
+}
+
+// Delete implements Store.
+func (c *threadSafeStore) Delete(obj interface{}) error {
+	key, err := c.keyFunc(obj)
+	if err != nil {
+		return KeyError{obj, err}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if oldObject, exists := c.items[key]; exists {
+		c.deleteFromIndices(oldObject, key)
+		delete(c.items, key)
+	}
+
+	return nil
+}
+
+// List implements Store.
+func (c *threadSafeStore) List() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	list := make([]interface{}, 0, len(c.items))
+	for _, item := range c.items {
+		list = append(list, item)
+	}
+
+	return list
+}
+
+// ListKeys implements Store.
+func (c *threadSafeStore) ListKeys() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	list := make([]string, 0, len(c.items))
+	for key := range c.items {
+		list = append(list, key)
+	}
+
+	return list
+}
+
+// Get implements Store.
+func (c *threadSafeStore) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	key, err := c.keyFunc(obj)
+	if err != nil {
+		return nil, false, KeyError{obj, err}
+	}
+
+	return c.GetByKey(key)
+}
+
+// GetByKey implements Store.
+func (c *threadSafeStore) GetByKey(key string) (item interface{}, exists bool, err error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	item, exists = c.items[key]
+
+	return item, exists, nil
+}
+
+// Replace implements Store.
+func (c *threadSafeStore) Replace(list []interface{}) error {
+	items := make(map[string]interface{}, len(list))
+
+	for _, item := range list {
+		key, err := c.keyFunc(item)
+		if err != nil {
+			return KeyError{item, err}
+		}
+
+		items[key] = item
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.items = items
+	c.indices = map[string]Index{}
+
+	for key, item := range c.items {
+		if err := c.updateIndices(nil, item, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Index implements Indexer.
+func (c *threadSafeStore) Index(indexName string, obj interface{}) ([]interface{}, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	indexFunc := c.indexers[indexName]
+	if indexFunc == nil {
+		return nil, fmt.Errorf("index with name %s does not exist", indexName)
+	}
+
+	indexedValues, err := indexFunc(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	index := c.indices[indexName]
+
+	storeKeys := map[string]struct{}{}
+	for _, indexedValue := range indexedValues {
+		for key := range index[indexedValue] {
+			storeKeys[key] = struct{}{}
+		}
+	}
+
+	list := make([]interface{}, 0, len(storeKeys))
+	for storeKey := range storeKeys {
+		list = append(list, c.items[storeKey])
+	}
+
+	return list, nil
+}
+
+// ByIndex implements Indexer.
+func (c *threadSafeStore) ByIndex(indexName, indexedValue string) ([]interface{}, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.indexers[indexName] == nil {
+		return nil, fmt.Errorf("index with name %s does not exist", indexName)
+	}
+
+	set := c.indices[indexName][indexedValue]
+
+	list := make([]interface{}, 0, len(set))
+	for key := range set {
+		list = append(list, c.items[key])
+	}
+
+	return list, nil
+}
+
+// IndexKeys implements Indexer.
+func (c *threadSafeStore) IndexKeys(indexName, indexedValue string) ([]string, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.indexers[indexName] == nil {
+		return nil, fmt.Errorf("index with name %s does not exist", indexName)
+	}
+
+	set := c.indices[indexName][indexedValue]
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// ListIndexFuncValues implements Indexer.
+func (c *threadSafeStore) ListIndexFuncValues(indexName string) []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	index := c.indices[indexName]
+
+	names := make([]string, 0, len(index))
+	for key := range index {
+		names = append(names, key)
+	}
+
+	return names
+}
+
+// GetIndexers implements Indexer.
+func (c *threadSafeStore) GetIndexers() Indexers {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	indexers := make(Indexers, len(c.indexers))
+	for name, indexFunc := range c.indexers {
+		indexers[name] = indexFunc
+	}
+
+	return indexers
+}
+
+// AddIndexers implements Indexer.
+func (c *threadSafeStore) AddIndexers(newIndexers Indexers) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.items) > 0 {
+		return fmt.Errorf("cannot add indexers to running index")
+	}
+
+	for name, indexFunc := range newIndexers {
+		if _, exists := c.indexers[name]; exists {
+			return fmt.Errorf("indexer conflict: %v", name)
+		}
+
+		c.indexers[name] = indexFunc
+	}
+
+	return nil
+}
+
+// updateIndices modifies the objects location in the managed indexes. If oldObj
+// is not nil, the object is removed from the indices of its previous values first.
+// It must be called with the lock held.
+func (c *threadSafeStore) updateIndices(oldObj, newObj interface{}, key string) error {
+	if oldObj != nil {
+		c.deleteFromIndices(oldObj, key)
+	}
+
+	for name, indexFunc := range c.indexers {
+		indexValues, err := indexFunc(newObj)
+		if err != nil {
+			return err
+		}
+
+		index := c.indices[name]
+		if index == nil {
+			index = Index{}
+			c.indices[name] = index
+		}
+
+		for _, indexValue := range indexValues {
+			set := index[indexValue]
+			if set == nil {
+				set = map[string]struct{}{}
+				index[indexValue] = set
+			}
+
+			set[key] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// deleteFromIndices removes the object from the managed indexes.
+// It must be called with the lock held.
+func (c *threadSafeStore) deleteFromIndices(obj interface{}, key string) {
+	for name, indexFunc := range c.indexers {
+		indexValues, err := indexFunc(obj)
+		if err != nil {
+			continue
+		}
+
+		index := c.indices[name]
+		if index == nil {
+			continue
+		}
+
+		for _, indexValue := range indexValues {
+			set := index[indexValue]
+			if set == nil {
+				continue
+			}
+
+			delete(set, key)
+
+			if len(set) == 0 {
+				delete(index, indexValue)
+			}
+		}
+	}
+}
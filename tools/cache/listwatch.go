@@ -0,0 +1,82 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+// ErrWatchUnsupported is returned by ListWatch.Watch when no WatchFunc is set.
+var ErrWatchUnsupported = errors.New("watch is not supported")
+
+// Lister is any object that knows how to perform an initial list.
+type Lister interface {
+	// List should return the complete list of objects.
+	List(ctx context.Context, options metav1.ListOptions) ([]interface{}, error)
+}
+
+// Watcher is any object that knows how to start a watch on a resource.
+type Watcher interface {
+	// Watch should begin a watch with the specified options.
+	Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error)
+}
+
+// ListerWatcher is any object that knows how to perform an initial list and start a watch on a resource.
+type ListerWatcher interface {
+	Lister
+	Watcher
+}
+
+// ListFunc knows how to list resources.
+type ListFunc func(ctx context.Context, options metav1.ListOptions) ([]interface{}, error)
+
+// WatchFunc knows how to watch resources.
+type WatchFunc func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error)
+
+// ListWatch knows how to list and watch a set of objects. It satisfies the ListerWatcher interface.
+// It is a convenience function for users of NewReflector, etc.
+// ListFunc must not be nil. WatchFunc is optional, a Reflector falls back to periodic listing without it.
+type ListWatch struct {
+	ListFunc  ListFunc
+	WatchFunc WatchFunc
+}
+
+var _ ListerWatcher = &ListWatch{}
+
+// List a set of objects.
+func (lw *ListWatch) List(ctx context.Context, options metav1.ListOptions) ([]interface{}, error) {
+	return lw.ListFunc(ctx, options)
+}
+
+// Watch a set of objects.
+func (lw *ListWatch) Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	if lw.WatchFunc == nil {
+		return nil, ErrWatchUnsupported
+	}
+
+	return lw.WatchFunc(ctx, options)
+}
+
+// isWatchUnsupported returns true if err indicates that the server has no watch
+// endpoint for the resource.
+func isWatchUnsupported(err error) bool {
+	if errors.Is(err, ErrWatchUnsupported) {
+		return true
+	}
+
+	switch rest.StatusCodeForError(err) {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
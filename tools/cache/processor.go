@@ -0,0 +1,173 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// ResourceEventHandler can handle notifications for events that
+// happen to a resource. The events are informational only, so you
+// can't return an error. The handlers MUST NOT modify the objects
+// received; this concerns not only the top level of structure but all
+// the data structures reachable from it.
+//   - OnAdd is called when an object is added.
+//   - OnUpdate is called when an object is modified. Note that oldObj is the
+//     last known state of the object-- it is possible that several changes
+//     were combined together, so you can't use this to see every single
+//     change. OnUpdate is also called when a re-list happens, and it will
+//     get called even if nothing changed. This is useful for periodically
+//     evaluating or syncing something.
+//   - OnDelete will get the final state of the item if it is known, otherwise
+//     it will get an object of type DeletedFinalStateUnknown. This can
+//     happen if the watch is closed and misses the delete event and we don't
+//     notice the deletion until the subsequent re-list.
+type ResourceEventHandler interface {
+	OnAdd(obj interface{})
+	OnUpdate(oldObj, newObj interface{})
+	OnDelete(obj interface{})
+}
+
+// ResourceEventHandlerFuncs is an adaptor to let you easily specify as many or
+// as few of the notification functions as you want while still implementing
+// ResourceEventHandler.
+type ResourceEventHandlerFuncs struct {
+	AddFunc    func(obj interface{})
+	UpdateFunc func(oldObj, newObj interface{})
+	DeleteFunc func(obj interface{})
+}
+
+// OnAdd calls AddFunc if it's not nil.
+func (r ResourceEventHandlerFuncs) OnAdd(obj interface{}) {
+	if r.AddFunc != nil {
+		r.AddFunc(obj)
+	}
+}
+
+// OnUpdate calls UpdateFunc if it's not nil.
+func (r ResourceEventHandlerFuncs) OnUpdate(oldObj, newObj interface{}) {
+	if r.UpdateFunc != nil {
+		r.UpdateFunc(oldObj, newObj)
+	}
+}
+
+// OnDelete calls DeleteFunc if it's not nil.
+func (r ResourceEventHandlerFuncs) OnDelete(obj interface{}) {
+	if r.DeleteFunc != nil {
+		r.DeleteFunc(obj)
+	}
+}
+
+type updateNotification struct {
+	oldObj interface{}
+	newObj interface{}
+}
+
+type addNotification struct {
+	newObj interface{}
+}
+
+type deleteNotification struct {
+	oldObj interface{}
+}
+
+// processorListener relays notifications from a sharedIndexInformer to
+// one ResourceEventHandler. It uses an unbounded buffer so that a slow
+// handler never blocks the informer nor the other handlers.
+type processorListener struct {
+	nextCh  chan interface{}
+	addCh   chan interface{}
+	handler ResourceEventHandler
+	// pending holds all the notifications that have not yet been distributed.
+	pending []interface{}
+}
+
+func newProcessListener(handler ResourceEventHandler) *processorListener {
+	return &processorListener{
+		nextCh:  make(chan interface{}),
+		addCh:   make(chan interface{}),
+		handler: handler,
+	}
+}
+
+// start launches the goroutines buffering and handling notifications.
+func (p *processorListener) start() {
+	go p.pop()
+	go p.run()
+}
+
+// stop stops the listener once the buffered notifications are handled.
+func (p *processorListener) stop() {
+	close(p.addCh)
+}
+
+// add queues a notification, it never blocks on the handler.
+func (p *processorListener) add(notification interface{}) {
+	p.addCh <- notification
+}
+
+// pop moves notifications from addCh to nextCh, buffering them in between.
+func (p *processorListener) pop() {
+	defer close(p.nextCh) // Tell .run() to stop
+
+	var (
+		nextCh       chan<- interface{}
+		notification interface{}
+	)
+
+	for {
+		select {
+		case nextCh <- notification:
+			// Notification dispatched
+			if len(p.pending) == 0 {
+				// Nothing to pop
+				nextCh = nil // Disable this select case
+				notification = nil
+
+				continue
+			}
+
+			notification, p.pending = p.pending[0], p.pending[1:]
+		case notificationToAdd, ok := <-p.addCh:
+			if !ok {
+				p.drain(nextCh, notification)
+				return
+			}
+
+			if notification == nil { // No notification to pop (and pendingNotifications is empty)
+				// Optimize the case - skip adding to pendingNotifications
+				notification = notificationToAdd
+				nextCh = p.nextCh
+			} else { // There is already a notification waiting to be dispatched
+				p.pending = append(p.pending, notificationToAdd)
+			}
+		}
+	}
+}
+
+// drain dispatches the remaining notifications once addCh was closed.
+func (p *processorListener) drain(nextCh chan<- interface{}, notification interface{}) {
+	if nextCh == nil {
+		return
+	}
+
+	nextCh <- notification
+
+	for _, notification := range p.pending {
+		nextCh <- notification
+	}
+
+	p.pending = nil
+}
+
+// run calls the handler for every notification.
+func (p *processorListener) run() {
+	for next := range p.nextCh {
+		switch notification := next.(type) {
+		case updateNotification:
+			p.handler.OnUpdate(notification.oldObj, notification.newObj)
+		case addNotification:
+			p.handler.OnAdd(notification.newObj)
+		case deleteNotification:
+			p.handler.OnDelete(notification.oldObj)
+		}
+	}
+}
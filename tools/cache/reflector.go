@@ -0,0 +1,229 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+const (
+	// defaultPollPeriod is how often a Reflector relists when the server does
+	// not support watch and no resync period is configured.
+	defaultPollPeriod = time.Minute
+
+	// minBackoff and maxBackoff bound the delay between failed ListAndWatch calls.
+	minBackoff = 800 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+// Resyncer is implemented by stores which want to be notified when a Reflector
+// resyncs, i.e. when it wants every cached object to be redelivered.
+type Resyncer interface {
+	Resync() error
+}
+
+// WatchErrorHandler is called whenever ListAndWatch drops the connection with an error.
+// After calling this handler, the reflector will backoff and retry ListAndWatch.
+type WatchErrorHandler func(r *Reflector, err error)
+
+// Reflector watches a specified resource and causes all changes to be reflected in the given store.
+// When the server does not support watch for the resource, the Reflector relists it periodically.
+type Reflector struct {
+	// name identifies this reflector in errors.
+	name string
+	// The destination to sync up with the watch source
+	store Store
+	// listerWatcher is used to perform lists and watches.
+	listerWatcher ListerWatcher
+	// resyncPeriod is the period at which the store is resynced, zero disables resyncs.
+	// When watch is not supported it is also the period at which the resource is relisted.
+	resyncPeriod time.Duration
+	// listOptions are passed to every list and watch call.
+	listOptions metav1.ListOptions
+	// watchErrorHandler is called with errors returned by ListAndWatch.
+	watchErrorHandler WatchErrorHandler
+}
+
+// NewReflector creates a new Reflector object which will keep the given store up to
+// date with the server's contents for the given resource. If resyncPeriod is non-zero,
+// then the reflector will periodically call Resync on stores implementing Resyncer.
+func NewReflector(name string, lw ListerWatcher, store Store, resyncPeriod time.Duration) *Reflector {
+	return &Reflector{
+		name:          name,
+		store:         store,
+		listerWatcher: lw,
+		resyncPeriod:  resyncPeriod,
+	}
+}
+
+// Name returns the name of the reflector.
+func (r *Reflector) Name() string {
+	return r.name
+}
+
+// SetWatchErrorHandler sets the handler called with errors returned by ListAndWatch.
+func (r *Reflector) SetWatchErrorHandler(handler WatchErrorHandler) {
+	r.watchErrorHandler = handler
+}
+
+// Run repeatedly uses the reflector's ListAndWatch to fetch all the
+// objects and subsequent deltas. Run will exit when ctx is done.
+func (r *Reflector) Run(ctx context.Context) {
+	backoff := minBackoff
+
+	for {
+		start := time.Now()
+		if err := r.ListAndWatch(ctx); err != nil && r.watchErrorHandler != nil && ctx.Err() == nil {
+			r.watchErrorHandler(r, err)
+		}
+
+		// reset the backoff once a list and watch cycle was healthy for a while
+		if time.Since(start) > maxBackoff {
+			backoff = minBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// ListAndWatch first lists all items and then watches them. If the server does
+// not support watch, the items are relisted periodically instead. It returns
+// when ctx is done, the watch ends or an error occurs.
+//
+// The watch is opened before the list: the list takes no resourceVersion, so the
+// changes made while listing are only seen through the events the watch holds
+// until they are applied after the list.
+func (r *Reflector) ListAndWatch(ctx context.Context) error {
+	w, err := r.listerWatcher.Watch(ctx, r.listOptions)
+	if err != nil {
+		if !isWatchUnsupported(err) {
+			return fmt.Errorf("%s: failed to watch: %w", r.name, err)
+		}
+
+		if err := r.list(ctx); err != nil {
+			return err
+		}
+
+		return r.poll(ctx)
+	}
+
+	if err := r.list(ctx); err != nil {
+		w.Stop()
+		return err
+	}
+
+	return r.watchHandler(ctx, w)
+}
+
+// list fetches all items and replaces the content of the store with them.
+func (r *Reflector) list(ctx context.Context) error {
+	list, err := r.listerWatcher.List(ctx, r.listOptions)
+	if err != nil {
+		return fmt.Errorf("%s: failed to list: %w", r.name, err)
+	}
+
+	if err := r.store.Replace(list); err != nil {
+		return fmt.Errorf("%s: unable to sync list result: %w", r.name, err)
+	}
+
+	return nil
+}
+
+// poll relists the items every resync period until ctx is done or listing fails.
+func (r *Reflector) poll(ctx context.Context) error {
+	period := r.resyncPeriod
+	if period <= 0 {
+		period = defaultPollPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.list(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchHandler applies watch events to the store and resyncs it periodically.
+func (r *Reflector) watchHandler(ctx context.Context, w watch.Interface) error {
+	defer w.Stop()
+
+	var resyncC <-chan time.Time
+
+	resyncer, canResync := r.store.(Resyncer)
+	if canResync && r.resyncPeriod > 0 {
+		ticker := time.NewTicker(r.resyncPeriod)
+		defer ticker.Stop()
+
+		resyncC = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-resyncC:
+			if err := resyncer.Resync(); err != nil {
+				return fmt.Errorf("%s: unable to resync: %w", r.name, err)
+			}
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				// the server closed the watch, relist and watch again
+				return nil
+			}
+
+			if err := r.handleEvent(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleEvent applies a single watch event to the store.
+func (r *Reflector) handleEvent(event watch.Event) error {
+	var err error
+
+	switch event.Type {
+	case watch.Added:
+		err = r.store.Add(event.Object)
+	case watch.Modified:
+		err = r.store.Update(event.Object)
+	case watch.Deleted:
+		err = r.store.Delete(event.Object)
+	case watch.Error:
+		if watchErr, ok := event.Object.(error); ok {
+			return fmt.Errorf("%s: watch error: %w", r.name, watchErr)
+		}
+
+		return fmt.Errorf("%s: watch error: %v", r.name, event.Object)
+	default:
+		return fmt.Errorf("%s: unable to understand watch event %#v", r.name, event)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: unable to apply watch event %s: %w", r.name, event.Type, err)
+	}
+
+	return nil
+}
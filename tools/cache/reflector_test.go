@@ -0,0 +1,66 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+func TestReflectorChangesDuringList(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		watches []*watch.FakeWatcher
+	)
+
+	// a watch only receives the changes made once it is open, as on the server
+	lw := &ListWatch{
+		ListFunc: func(context.Context, metav1.ListOptions) ([]interface{}, error) {
+			list := []interface{}{newUser("user-1", "foo")}
+
+			// user-2 is created once the list was read
+			lock.Lock()
+			defer lock.Unlock()
+
+			for _, w := range watches {
+				w.Add(newUser("user-2", "bar"))
+			}
+
+			return list, nil
+		},
+		WatchFunc: func(context.Context, metav1.ListOptions) (watch.Interface, error) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			w := watch.NewFakeWithChanSize(10)
+			watches = append(watches, w)
+
+			return w, nil
+		},
+	}
+
+	store := NewStore(MetaInstanceIDKeyFunc)
+	reflector := NewReflector("users", lw, store, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go reflector.ListAndWatch(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(store.ListKeys()) != 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, exists, _ := store.GetByKey("user-2"); !exists {
+		t.Errorf("expected the user created during the list to be cached, got %v", store.ListKeys())
+	}
+}
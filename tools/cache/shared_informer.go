@@ -0,0 +1,362 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SharedInformer provides eventually consistent linkage of its
+// clients to the authoritative state of a given collection of
+// objects. An object is identified by its instance id.
+//
+// A SharedInformer maintains a local cache, exposed by GetStore(), of
+// the state of each relevant object. This cache is eventually
+// consistent with the authoritative state.
+//
+// A client is identified here by a ResourceEventHandler. For every
+// update to the SharedInformer's local cache and for every client
+// added before Run(), eventually either the SharedInformer is
+// stopped or the client is notified of the update. A client added
+// after Run() starts gets a startup batch of notifications of
+// additions of the objects existing in the cache at the time that
+// client was added.
+type SharedInformer interface {
+	// AddEventHandler adds an event handler to the shared informer. Events to a
+	// single handler are delivered sequentially, but there is no coordination
+	// between different handlers.
+	AddEventHandler(handler ResourceEventHandler)
+	// GetStore returns the informer's local cache as a Store.
+	GetStore() Store
+	// Run starts and runs the shared informer, returning after it stops.
+	// The informer will be stopped when ctx is done.
+	Run(ctx context.Context)
+	// HasSynced returns true if the shared informer's store has been
+	// informed by at least one full LIST of the authoritative state
+	// of the informer's object collection.
+	HasSynced() bool
+	// SetWatchErrorHandler sets the handler called whenever ListAndWatch drops
+	// the connection with an error. It must be called before Run.
+	SetWatchErrorHandler(handler WatchErrorHandler) error
+}
+
+// SharedIndexInformer provides add and get Indexers ability based on SharedInformer.
+type SharedIndexInformer interface {
+	SharedInformer
+	// AddIndexers add indexers to the informer before it starts.
+	AddIndexers(indexers Indexers) error
+	// GetIndexer returns the informer's local cache as an Indexer.
+	GetIndexer() Indexer
+}
+
+// InformerSynced is a function that can be used to determine if an informer has synced.
+// This is useful for determining if caches have synced.
+type InformerSynced func() bool
+
+// syncedPollPeriod controls how often you look at the status of your sync funcs.
+const syncedPollPeriod = 100 * time.Millisecond
+
+// WaitForCacheSync waits for caches to populate. It returns true if it was successful,
+// false if the context was done before all caches synced.
+func WaitForCacheSync(ctx context.Context, cacheSyncs ...InformerSynced) bool {
+	ticker := time.NewTicker(syncedPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		synced := true
+
+		for _, syncFunc := range cacheSyncs {
+			if !syncFunc() {
+				synced = false
+				break
+			}
+		}
+
+		if synced {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewSharedInformer creates a new instance for the ListerWatcher. See NewSharedIndexInformer for details.
+func NewSharedInformer(lw ListerWatcher, defaultEventHandlerResyncPeriod time.Duration) SharedInformer {
+	return NewSharedIndexInformer(lw, defaultEventHandlerResyncPeriod, Indexers{})
+}
+
+// NewSharedIndexInformer creates a new instance for the ListerWatcher and specified Indexers.
+// Objects are keyed with MetaInstanceIDKeyFunc. If defaultEventHandlerResyncPeriod is
+// non-zero, every cached object is periodically redelivered to the handlers as an update.
+// When the server does not support watch, the collection is relisted at the same period.
+func NewSharedIndexInformer(
+	lw ListerWatcher,
+	defaultEventHandlerResyncPeriod time.Duration,
+	indexers Indexers,
+) SharedIndexInformer {
+	return &sharedIndexInformer{
+		indexer:       NewIndexer(MetaInstanceIDKeyFunc, indexers),
+		listerWatcher: lw,
+		resyncPeriod:  defaultEventHandlerResyncPeriod,
+	}
+}
+
+// sharedIndexInformer implements SharedIndexInformer. It is also the Store
+// its Reflector writes to, so that every change to the indexer is
+// dispatched to the registered listeners.
+type sharedIndexInformer struct {
+	indexer       Indexer
+	listerWatcher ListerWatcher
+	resyncPeriod  time.Duration
+
+	watchErrorHandler WatchErrorHandler
+
+	// blockDeltas gives a way to stop all event distribution so that a late event handler
+	// can safely join the shared informer.
+	blockDeltas sync.Mutex
+	listeners   []*processorListener
+	started     bool
+	stopped     bool
+
+	synced atomic.Bool
+}
+
+var (
+	_ SharedIndexInformer = &sharedIndexInformer{}
+	_ Store               = &sharedIndexInformer{}
+	_ Resyncer            = &sharedIndexInformer{}
+)
+
+// AddEventHandler implements SharedInformer.
+func (s *sharedIndexInformer) AddEventHandler(handler ResourceEventHandler) {
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	listener := newProcessListener(handler)
+	s.listeners = append(s.listeners, listener)
+
+	if !s.started {
+		return
+	}
+
+	listener.start()
+
+	for _, item := range s.indexer.List() {
+		listener.add(addNotification{newObj: item})
+	}
+}
+
+// GetStore implements SharedInformer.
+func (s *sharedIndexInformer) GetStore() Store {
+	return s.indexer
+}
+
+// GetIndexer implements SharedIndexInformer.
+func (s *sharedIndexInformer) GetIndexer() Indexer {
+	return s.indexer
+}
+
+// AddIndexers implements SharedIndexInformer.
+func (s *sharedIndexInformer) AddIndexers(indexers Indexers) error {
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+
+	return s.indexer.AddIndexers(indexers)
+}
+
+// HasSynced implements SharedInformer.
+func (s *sharedIndexInformer) HasSynced() bool {
+	return s.synced.Load()
+}
+
+// SetWatchErrorHandler implements SharedInformer.
+func (s *sharedIndexInformer) SetWatchErrorHandler(handler WatchErrorHandler) error {
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+
+	s.watchErrorHandler = handler
+
+	return nil
+}
+
+// Run implements SharedInformer.
+func (s *sharedIndexInformer) Run(ctx context.Context) {
+	s.blockDeltas.Lock()
+	if s.started || s.stopped {
+		s.blockDeltas.Unlock()
+		return
+	}
+
+	s.started = true
+	for _, listener := range s.listeners {
+		listener.start()
+	}
+
+	reflector := NewReflector(fmt.Sprintf("%T", s.listerWatcher), s.listerWatcher, s, s.resyncPeriod)
+	reflector.SetWatchErrorHandler(s.watchErrorHandler)
+	s.blockDeltas.Unlock()
+
+	reflector.Run(ctx)
+
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	s.stopped = true
+	for _, listener := range s.listeners {
+		listener.stop()
+	}
+}
+
+// distribute sends the notification to every listener. It must be called with
+// blockDeltas held.
+func (s *sharedIndexInformer) distribute(notification interface{}) {
+	for _, listener := range s.listeners {
+		listener.add(notification)
+	}
+}
+
+// Add implements Store, it is called by the Reflector.
+func (s *sharedIndexInformer) Add(obj interface{}) error {
+	return s.Update(obj)
+}
+
+// Update implements Store, it is called by the Reflector.
+func (s *sharedIndexInformer) Update(obj interface{}) error {
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	old, exists, err := s.indexer.Get(obj)
+	if err != nil {
+		return err
+	}
+
+	if err := s.indexer.Update(obj); err != nil {
+		return err
+	}
+
+	if exists {
+		s.distribute(updateNotification{oldObj: old, newObj: obj})
+	} else {
+		s.distribute(addNotification{newObj: obj})
+	}
+
+	return nil
+}
+
+// Delete implements Store, it is called by the Reflector.
+func (s *sharedIndexInformer) Delete(obj interface{}) error {
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	if _, exists, err := s.indexer.Get(obj); err != nil || !exists {
+		return err
+	}
+
+	if err := s.indexer.Delete(obj); err != nil {
+		return err
+	}
+
+	s.distribute(deleteNotification{oldObj: obj})
+
+	return nil
+}
+
+// List implements Store.
+func (s *sharedIndexInformer) List() []interface{} {
+	return s.indexer.List()
+}
+
+// ListKeys implements Store.
+func (s *sharedIndexInformer) ListKeys() []string {
+	return s.indexer.ListKeys()
+}
+
+// Get implements Store.
+func (s *sharedIndexInformer) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	return s.indexer.Get(obj)
+}
+
+// GetByKey implements Store.
+func (s *sharedIndexInformer) GetByKey(key string) (item interface{}, exists bool, err error) {
+	return s.indexer.GetByKey(key)
+}
+
+// Replace implements Store, it is called by the Reflector after every list.
+// Objects missing from list are delivered as DeletedFinalStateUnknown.
+func (s *sharedIndexInformer) Replace(list []interface{}) error {
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	keys := make(map[string]struct{}, len(list))
+	notifications := make([]interface{}, 0, len(list))
+
+	for _, item := range list {
+		key, err := MetaInstanceIDKeyFunc(item)
+		if err != nil {
+			return KeyError{item, err}
+		}
+
+		keys[key] = struct{}{}
+
+		if old, exists, _ := s.indexer.GetByKey(key); exists {
+			notifications = append(notifications, updateNotification{oldObj: old, newObj: item})
+		} else {
+			notifications = append(notifications, addNotification{newObj: item})
+		}
+	}
+
+	for _, key := range s.indexer.ListKeys() {
+		if _, found := keys[key]; found {
+			continue
+		}
+
+		old, _, _ := s.indexer.GetByKey(key)
+		notifications = append(notifications, deleteNotification{oldObj: DeletedFinalStateUnknown{Key: key, Obj: old}})
+	}
+
+	if err := s.indexer.Replace(list); err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		s.distribute(notification)
+	}
+
+	s.synced.Store(true)
+
+	return nil
+}
+
+// Resync implements Resyncer, it redelivers every cached object as an update.
+func (s *sharedIndexInformer) Resync() error {
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+
+	for _, item := range s.indexer.List() {
+		s.distribute(updateNotification{oldObj: item, newObj: item})
+	}
+
+	return nil
+}
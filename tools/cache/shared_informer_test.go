@@ -0,0 +1,164 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+	v1 "github.com/coding-hui/iam/pkg/api/apiserver/v1"
+
+	"github.com/coding-hui/wecoding-sdk-go/watch"
+)
+
+func newUser(id, name string) *v1.DetailUserResponse {
+	user := &v1.DetailUserResponse{}
+	user.InstanceID = id
+	user.Name = name
+
+	return user
+}
+
+// recorder is a ResourceEventHandler recording the keys of received events.
+type recorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string, obj interface{}) {
+	key, _ := MetaInstanceIDKeyFunc(obj)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.events = append(r.events, event+":"+key)
+}
+
+func (r *recorder) OnAdd(obj interface{})       { r.record("add", obj) }
+func (r *recorder) OnUpdate(_, obj interface{}) { r.record("update", obj) }
+func (r *recorder) OnDelete(obj interface{})    { r.record("delete", obj) }
+
+func (r *recorder) waitFor(t *testing.T, expected ...string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.lock.Lock()
+		n := len(r.events)
+		r.lock.Unlock()
+
+		if n >= len(expected) {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.events) != len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, r.events)
+	}
+
+	seen := map[string]int{}
+	for _, event := range r.events {
+		seen[event]++
+	}
+
+	for _, event := range expected {
+		if seen[event] == 0 {
+			t.Fatalf("expected events %v, got %v", expected, r.events)
+		}
+		seen[event]--
+	}
+
+	r.events = nil
+}
+
+func TestSharedIndexInformerWatch(t *testing.T) {
+	fakeWatch := watch.NewFakeWithChanSize(10)
+	lw := &ListWatch{
+		ListFunc: func(context.Context, metav1.ListOptions) ([]interface{}, error) {
+			return []interface{}{newUser("user-1", "foo"), newUser("user-2", "bar")}, nil
+		},
+		WatchFunc: func(context.Context, metav1.ListOptions) (watch.Interface, error) {
+			return fakeWatch, nil
+		},
+	}
+
+	informer := NewSharedIndexInformer(lw, 0, Indexers{NameIndex: MetaNameIndexFunc})
+	handler := &recorder{}
+	informer.AddEventHandler(handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go informer.Run(ctx)
+
+	if !WaitForCacheSync(ctx, informer.HasSynced) {
+		t.Fatalf("cache did not sync")
+	}
+
+	handler.waitFor(t, "add:user-1", "add:user-2")
+
+	fakeWatch.Modify(newUser("user-1", "baz"))
+	fakeWatch.Delete(newUser("user-2", "bar"))
+	fakeWatch.Add(newUser("user-3", "qux"))
+	handler.waitFor(t, "update:user-1", "delete:user-2", "add:user-3")
+
+	items, err := informer.GetIndexer().ByIndex(NameIndex, "baz")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected one user named baz, got %v (%v)", items, err)
+	}
+
+	// A late handler receives the current content of the cache.
+	late := &recorder{}
+	informer.AddEventHandler(late)
+	late.waitFor(t, "add:user-1", "add:user-3")
+}
+
+func TestSharedIndexInformerPoll(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		lists = [][]interface{}{
+			{newUser("user-1", "foo"), newUser("user-2", "bar")},
+			{newUser("user-2", "bar")},
+		}
+	)
+
+	lw := &ListWatch{
+		ListFunc: func(context.Context, metav1.ListOptions) ([]interface{}, error) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			list := lists[0]
+			if len(lists) > 1 {
+				lists = lists[1:]
+			}
+
+			return list, nil
+		},
+	}
+
+	informer := NewSharedInformer(lw, 200*time.Millisecond)
+	handler := &recorder{}
+	informer.AddEventHandler(handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go informer.Run(ctx)
+
+	handler.waitFor(t, "add:user-1", "add:user-2")
+	handler.waitFor(t, "delete:user-1", "update:user-2")
+
+	if _, exists, _ := informer.GetStore().GetByKey("user-1"); exists {
+		t.Errorf("expected user-1 to be deleted from the store")
+	}
+}
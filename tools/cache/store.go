@@ -0,0 +1,100 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+
+	metav1 "github.com/coding-hui/common/meta/v1"
+)
+
+// Store is a generic object storage interface. Reflector knows how to watch a server
+// and update a store. A generic store is provided, which allows Reflector to be used
+// as a local caching system.
+type Store interface {
+	// Add adds the given object to the accumulator associated with the given object's key
+	Add(obj interface{}) error
+
+	// Update updates the given object in the accumulator associated with the given object's key
+	Update(obj interface{}) error
+
+	// Delete deletes the given object from the accumulator associated with the given object's key
+	Delete(obj interface{}) error
+
+	// List returns a list of all the currently non-empty accumulators
+	List() []interface{}
+
+	// ListKeys returns a list of all the keys currently associated with non-empty accumulators
+	ListKeys() []string
+
+	// Get returns the accumulator associated with the given object's key
+	Get(obj interface{}) (item interface{}, exists bool, err error)
+
+	// GetByKey returns the accumulator associated with the given key
+	GetByKey(key string) (item interface{}, exists bool, err error)
+
+	// Replace will delete the contents of the store, using instead the
+	// given list. Store takes ownership of the list, you should not reference
+	// it after calling this function.
+	Replace(list []interface{}) error
+}
+
+// KeyFunc knows how to make a key from an object. Implementations should be deterministic.
+type KeyFunc func(obj interface{}) (string, error)
+
+// KeyError will be returned any time a KeyFunc gives an error; it includes the object
+// at fault.
+type KeyError struct {
+	Obj interface{}
+	Err error
+}
+
+// Error gives a human-readable description of the error.
+func (k KeyError) Error() string {
+	return fmt.Sprintf("couldn't create key for object %+v: %v", k.Obj, k.Err)
+}
+
+// Unwrap implements errors.Unwrap.
+func (k KeyError) Unwrap() error {
+	return k.Err
+}
+
+// ExplicitKey can be passed to MetaInstanceIDKeyFunc if you have the key for
+// the object but not the object itself.
+type ExplicitKey string
+
+// DeletedFinalStateUnknown is placed into the store by the informer when an object
+// was deleted while the watch was disconnected. In that case the last known state
+// of the object may be stale.
+type DeletedFinalStateUnknown struct {
+	Key string
+	Obj interface{}
+}
+
+// MetaInstanceIDKeyFunc is a convenient default KeyFunc which knows how to make
+// keys for API objects which implement metav1.ObjectMetaAccessor. The key is the
+// instance id of the object.
+func MetaInstanceIDKeyFunc(obj interface{}) (string, error) {
+	switch o := obj.(type) {
+	case ExplicitKey:
+		return string(o), nil
+	case DeletedFinalStateUnknown:
+		return o.Key, nil
+	case metav1.ObjectMetaAccessor:
+		meta := o.GetObjectMeta()
+		if len(meta.GetInstanceID()) == 0 {
+			return "", fmt.Errorf("object has no instance id")
+		}
+
+		return meta.GetInstanceID(), nil
+	default:
+		return "", fmt.Errorf("object does not implement the ObjectMetaAccessor interface")
+	}
+}
+
+// NewStore returns a Store implemented simply with a map and a lock.
+func NewStore(keyFunc KeyFunc) Store {
+	return NewIndexer(keyFunc, Indexers{})
+}
@@ -6,14 +6,18 @@ package clientcmd
 
 import (
 	"net/url"
+	"reflect"
 	"time"
 
+	"github.com/coding-hui/wecoding-sdk-go/plugin/pkg/client/auth/exec"
 	restclient "github.com/coding-hui/wecoding-sdk-go/rest"
+	"github.com/coding-hui/wecoding-sdk-go/transport"
 )
 
 // Server contains information about how to communicate with a iam api server.
 type Server struct {
-	LocationOfOrigin string
+	// LocationOfOrigin indicates where this object came from. It is not serialized.
+	LocationOfOrigin string        `yaml:"-" mapstructure:"-"`
 	Timeout          time.Duration `yaml:"timeout,omitempty"                    mapstructure:"timeout,omitempty"`
 	MaxRetries       int           `yaml:"max-retries,omitempty"                mapstructure:"max-retries,omitempty"`
 	RetryInterval    time.Duration `yaml:"retry-interval,omitempty"             mapstructure:"retry-interval,omitempty"`
@@ -33,12 +37,28 @@ type Server struct {
 	// Overrides CertificateAuthority
 	// +optional
 	CertificateAuthorityData string `yaml:"certificate-authority-data,omitempty" mapstructure:"certificate-authority-data,omitempty"`
+	// ProxyURL is the URL of the http, https, socks5 or socks5h proxy the requests
+	// to the server are sent through. If empty, the HTTPS_PROXY, HTTP_PROXY and
+	// NO_PROXY environment variables are honored.
+	// +optional
+	ProxyURL string `yaml:"proxy-url,omitempty"                  mapstructure:"proxy-url,omitempty"`
+	// ReloadTLSFiles reads the certificate-authority file of the server, and the
+	// client-certificate and client-key files of the user, again when they change,
+	// so the certificates rotated by the platform are used without restarting.
+	// +optional
+	ReloadTLSFiles bool `yaml:"reload-tls-files,omitempty"           mapstructure:"reload-tls-files,omitempty"`
+
+	// Extensions holds the fields not understood by this version, they are
+	// preserved when the config is written.
+	// +optional
+	Extensions map[string]interface{} `yaml:",inline" mapstructure:",remain"`
 }
 
 // AuthInfo contains information that describes identity information.
 // This is use to tell the iam cluster who you are.
 type AuthInfo struct {
-	LocationOfOrigin  string
+	// LocationOfOrigin indicates where this object came from. It is not serialized.
+	LocationOfOrigin  string `yaml:"-"                                 mapstructure:"-"`
 	ClientCertificate string `yaml:"client-certificate,omitempty"      mapstructure:"client-certificate,omitempty"`
 	// ClientCertificateData contains PEM-encoded data from a client cert file for TLS. Overrides ClientCertificate
 	// +optional
@@ -58,20 +78,83 @@ type AuthInfo struct {
 
 	SecretID  string `yaml:"secret-id,omitempty"  mapstructure:"secret-id,omitempty"`
 	SecretKey string `yaml:"secret-key,omitempty" mapstructure:"secret-key,omitempty"`
+
+	// Exec specifies a command to run to obtain the credentials, e.g. an SSO helper.
+	// +optional
+	Exec *ExecConfig `yaml:"exec,omitempty" mapstructure:"exec,omitempty"`
+
+	// Impersonate is the username to impersonate. The name matches the flag.
+	// +optional
+	Impersonate string `yaml:"as,omitempty"            mapstructure:"as,omitempty"`
+	// ImpersonateGroups is the groups to impersonate.
+	// +optional
+	ImpersonateGroups []string `yaml:"as-groups,omitempty"     mapstructure:"as-groups,omitempty"`
+	// ImpersonateUserExtra contains additional information for impersonated user.
+	// +optional
+	ImpersonateUserExtra map[string][]string `yaml:"as-user-extra,omitempty" mapstructure:"as-user-extra,omitempty"`
+
+	// Extensions holds the fields not understood by this version, they are
+	// preserved when the config is written.
+	// +optional
+	Extensions map[string]interface{} `yaml:",inline" mapstructure:",remain"`
 }
 
-// Config defines a config struct used by marmotedu-sdk-go.
+// ExecConfig specifies a command to run to obtain credentials, see the exec package.
+type ExecConfig = exec.Config
+
+// ExecEnvVar is an environment variable of an exec command.
+type ExecEnvVar = exec.EnvVar
+
+// Context is a tuple of references to a server (how do I communicate with an iam
+// server) and a user (how do I identify myself).
+type Context struct {
+	// LocationOfOrigin indicates where this object came from. It is not serialized.
+	LocationOfOrigin string `yaml:"-" mapstructure:"-"`
+	// Server is the name of the server for this context.
+	Server string `yaml:"server"         mapstructure:"server"`
+	// AuthInfo is the name of the authInfo for this context.
+	AuthInfo string `yaml:"user,omitempty" mapstructure:"user,omitempty"`
+
+	// Extensions holds the fields not understood by this version, they are
+	// preserved when the config is written.
+	// +optional
+	Extensions map[string]interface{} `yaml:",inline" mapstructure:",remain"`
+}
+
+// Config defines a config struct used by wecoding-sdk-go. It holds named servers,
+// users and contexts, like a kubeconfig. The single Server and AuthInfo of the
+// original format are still supported and used when no context is chosen.
 type Config struct {
-	APIVersion string    `yaml:"apiVersion,omitempty" mapstructure:"apiVersion,omitempty"`
-	AuthInfo   *AuthInfo `yaml:"user,omitempty"       mapstructure:"user,omitempty"`
-	Server     *Server   `yaml:"server,omitempty"     mapstructure:"server,omitempty"`
+	// APIVersion is the version of the schema of the config, see LatestAPIVersion.
+	APIVersion string `yaml:"apiVersion,omitempty" mapstructure:"apiVersion,omitempty"`
+	// Servers is a map of referencable names to server configs.
+	Servers map[string]*Server `yaml:"servers,omitempty"         mapstructure:"servers,omitempty"`
+	// AuthInfos is a map of referencable names to user configs.
+	AuthInfos map[string]*AuthInfo `yaml:"users,omitempty"           mapstructure:"users,omitempty"`
+	// Contexts is a map of referencable names to context configs.
+	Contexts map[string]*Context `yaml:"contexts,omitempty"        mapstructure:"contexts,omitempty"`
+	// CurrentContext is the name of the context that you would like to use by default.
+	CurrentContext string `yaml:"current-context,omitempty" mapstructure:"current-context,omitempty"`
+
+	// AuthInfo is the user used when no context is chosen.
+	AuthInfo *AuthInfo `yaml:"user,omitempty"   mapstructure:"user,omitempty"`
+	// Server is the server used when no context is chosen.
+	Server *Server `yaml:"server,omitempty" mapstructure:"server,omitempty"`
+
+	// Extensions holds the fields not understood by this version, they are
+	// preserved when the config is written.
+	// +optional
+	Extensions map[string]interface{} `yaml:",inline" mapstructure:",remain"`
 }
 
 // NewConfig is a convenience function that returns a new Config object with non-nil maps.
 func NewConfig() *Config {
 	return &Config{
-		Server:   &Server{},
-		AuthInfo: &AuthInfo{},
+		Servers:   make(map[string]*Server),
+		AuthInfos: make(map[string]*AuthInfo),
+		Contexts:  make(map[string]*Context),
+		Server:    &Server{},
+		AuthInfo:  &AuthInfo{},
 	}
 }
 
@@ -84,11 +167,30 @@ type ClientConfig interface {
 // DirectClientConfig wrap for Config.
 type DirectClientConfig struct {
 	config Config
+	// overrides are applied on top of config.
+	overrides ConfigOverrides
 }
 
-// NewClientConfigFromConfig takes your Config and gives you back a ClientConfig.
+// NewClientConfigFromConfig takes your Config and gives you back a ClientConfig
+// using its current context.
 func NewClientConfigFromConfig(config *Config) ClientConfig {
-	return &DirectClientConfig{*config}
+	return &DirectClientConfig{config: *config}
+}
+
+// NewNonInteractiveClientConfig creates a ClientConfig using the context of config
+// named contextName. An empty contextName means the current context of config.
+func NewNonInteractiveClientConfig(config Config, contextName string) ClientConfig {
+	return &DirectClientConfig{config: config, overrides: ConfigOverrides{CurrentContext: contextName}}
+}
+
+// NewClientConfigWithOverrides creates a ClientConfig using config with overrides
+// applied on top of it. A nil overrides is the same as no overrides.
+func NewClientConfigWithOverrides(config Config, overrides *ConfigOverrides) ClientConfig {
+	if overrides == nil {
+		overrides = &ConfigOverrides{}
+	}
+
+	return &DirectClientConfig{config: config, overrides: *overrides}
 }
 
 // NewClientConfigFromBytes takes your iamconfig and gives you back a ClientConfig.
@@ -98,7 +200,7 @@ func NewClientConfigFromBytes(configBytes []byte) (ClientConfig, error) {
 		return nil, err
 	}
 
-	return &DirectClientConfig{*config}, nil
+	return &DirectClientConfig{config: *config}, nil
 }
 
 // RESTConfigFromIAMConfig is a convenience method to give back a restconfig from your iamconfig bytes.
@@ -114,13 +216,13 @@ func RESTConfigFromIAMConfig(configBytes []byte) (*restclient.Config, error) {
 
 // ClientConfig implements ClientConfig.
 func (config *DirectClientConfig) ClientConfig() (*restclient.Config, error) {
-	user := config.getAuthInfo()
-	server := config.getServer()
-
 	if err := config.ConfirmUsable(); err != nil {
 		return nil, err
 	}
 
+	user := config.getAuthInfo()
+	server := config.getServer()
+
 	clientConfig := &restclient.Config{
 		BearerToken:   user.Token,
 		Username:      user.Username,
@@ -132,16 +234,47 @@ func (config *DirectClientConfig) ClientConfig() (*restclient.Config, error) {
 		MaxRetries:    server.MaxRetries,
 		RetryInterval: server.RetryInterval,
 		TLSClientConfig: restclient.TLSClientConfig{
-			Insecure:   server.InsecureSkipTLSVerify,
-			ServerName: server.TLSServerName,
-			CertFile:   user.ClientCertificate,
-			KeyFile:    user.ClientKey,
-			CertData:   []byte(user.ClientCertificateData),
-			KeyData:    []byte(user.ClientKeyData),
-			CAFile:     server.CertificateAuthority,
-			CAData:     []byte(server.CertificateAuthorityData),
+			Insecure:       server.InsecureSkipTLSVerify,
+			ServerName:     server.TLSServerName,
+			CertFile:       user.ClientCertificate,
+			KeyFile:        user.ClientKey,
+			CertData:       []byte(user.ClientCertificateData),
+			KeyData:        []byte(user.ClientKeyData),
+			CAFile:         server.CertificateAuthority,
+			CAData:         []byte(server.CertificateAuthorityData),
+			ReloadTLSFiles: server.ReloadTLSFiles,
 			// NextProtos []string
 		},
+		Impersonate: transport.ImpersonationConfig{
+			UserName: user.Impersonate,
+			Groups:   user.ImpersonateGroups,
+			Extra:    user.ImpersonateUserExtra,
+		},
+	}
+
+	if len(server.ProxyURL) != 0 {
+		proxy, err := restclient.ProxyURL(server.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+
+		clientConfig.Proxy = proxy
+	}
+
+	if len(config.overrides.Timeout) != 0 {
+		// validated by ConfirmUsable
+		clientConfig.Timeout, _ = ParseTimeout(config.overrides.Timeout)
+	}
+
+	clientConfig.Debug = config.overrides.Debug
+
+	if user.Exec != nil {
+		provider, err := exec.NewAuthenticator(user.Exec)
+		if err != nil {
+			return nil, err
+		}
+
+		clientConfig.CredentialProvider = provider
 	}
 
 	if u, err := url.ParseRequestURI(clientConfig.Host); err == nil && u.Opaque == "" && len(u.Path) > 1 {
@@ -159,6 +292,21 @@ func (config *DirectClientConfig) ClientConfig() (*restclient.Config, error) {
 func (config *DirectClientConfig) ConfirmUsable() error {
 	validationErrors := make([]error, 0)
 
+	contextName, context, err := config.getContext()
+	if err != nil {
+		return newErrConfigurationInvalid([]error{err})
+	}
+
+	if context != nil {
+		validationErrors = append(validationErrors, validateContext(contextName, *context, config.config)...)
+	}
+
+	if len(config.overrides.Timeout) != 0 {
+		if _, err := ParseTimeout(config.overrides.Timeout); err != nil {
+			validationErrors = append(validationErrors, err)
+		}
+	}
+
 	authInfo := config.getAuthInfo()
 	validationErrors = append(validationErrors, validateAuthInfo(authInfo)...)
 	server := config.getServer()
@@ -172,32 +320,119 @@ func (config *DirectClientConfig) ConfirmUsable() error {
 	return newErrConfigurationInvalid(validationErrors)
 }
 
-// getAuthInfo returns the clientcmdapi.AuthInfo, or an error if a required auth info is not found.
+// getContextName returns the name of the context to use, empty when none is chosen.
+func (config *DirectClientConfig) getContextName() string {
+	if len(config.overrides.CurrentContext) != 0 {
+		return config.overrides.CurrentContext
+	}
+
+	return config.config.CurrentContext
+}
+
+// getContext returns the context to use, nil when no context is chosen, or an error
+// if the chosen context does not exist. When no context is chosen, the single Server
+// and AuthInfo of the config are used, unless the config only has named servers.
+func (config *DirectClientConfig) getContext() (string, *Context, error) {
+	contextName := config.getContextName()
+	if len(contextName) == 0 {
+		if len(config.config.Servers) != 0 && isEmptyServer(config.config.Server) {
+			return "", nil, ErrNoContext
+		}
+
+		return "", nil, nil
+	}
+
+	context, exists := config.config.Contexts[contextName]
+	if !exists || context == nil {
+		return "", nil, &errContextNotFound{contextName}
+	}
+
+	return contextName, context, nil
+}
+
+// getAuthInfo returns the AuthInfo of the chosen context, an empty one if it is not found,
+// with the overrides applied.
 func (config *DirectClientConfig) getAuthInfo() AuthInfo {
-	return *config.config.AuthInfo
+	var authInfo *AuthInfo
+
+	if _, context, _ := config.getContext(); context != nil {
+		authInfo = config.config.AuthInfos[context.AuthInfo]
+	} else {
+		authInfo = config.config.AuthInfo
+	}
+
+	var result AuthInfo
+	if authInfo != nil {
+		result = *authInfo
+	}
+
+	config.overrides.mergeAuthInfo(&result)
+
+	return result
 }
 
-// getServer returns the clientcmdapi.Cluster, or an error if a required cluster is not found.
+// getServer returns the Server of the chosen context, an empty one if it is not found,
+// with the overrides applied.
 func (config *DirectClientConfig) getServer() Server {
-	return *config.config.Server
+	var server *Server
+
+	if _, context, _ := config.getContext(); context != nil {
+		server = config.config.Servers[context.Server]
+	} else {
+		server = config.config.Server
+	}
+
+	var result Server
+	if server != nil {
+		result = *server
+	}
+
+	config.overrides.mergeServer(&result)
+
+	return result
 }
 
-// BuildConfigFromFlags is a helper function that builds configs from a master
-// url or a iamconfig filepath. These are passed in as command line flags for cluster
-// components. Warnings should reflect this usage. If neither masterUrl or iamconfigPath
-// are passed in we fallback to inClusterConfig. If inClusterConfig fails, we fallback
-// to the default config.
-func BuildConfigFromFlags(serverURL, iamconfigPath string) (*restclient.Config, error) {
-	config, err := LoadFromFile(iamconfigPath)
-	if err != nil {
-		return nil, err
+// isEmptyServer returns whether server is not configured.
+func isEmptyServer(server *Server) bool {
+	if server == nil {
+		return true
 	}
 
-	if len(serverURL) > 0 {
-		config.Server.Address = serverURL
+	// where the server was loaded from is not part of its configuration
+	empty := *server
+	empty.LocationOfOrigin = ""
+
+	if len(empty.Extensions) == 0 {
+		empty.Extensions = nil
 	}
 
-	directClientConfig := &DirectClientConfig{*config}
+	return reflect.DeepEqual(empty, Server{})
+}
+
+// BuildConfigFromFlags is a helper function that builds configs from a server
+// url or a iamconfig filepath. These are passed in as command line flags for cluster
+// components. Warnings should reflect this usage. If iamconfigPath is not passed in,
+// the default loading rules are used, see NewDefaultClientConfigLoadingRules.
+func BuildConfigFromFlags(serverURL, iamconfigPath string) (*restclient.Config, error) {
+	return BuildConfigFromFlagsWithContext(serverURL, iamconfigPath, "")
+}
+
+// BuildConfigFromFlagsWithContext is like BuildConfigFromFlags but uses the context
+// of the iamconfig named contextName, or its current context if contextName is empty.
+func BuildConfigFromFlagsWithContext(serverURL, iamconfigPath, contextName string) (*restclient.Config, error) {
+	return BuildConfigFromOverrides(&ConfigOverrides{
+		IAMConfig:      iamconfigPath,
+		CurrentContext: contextName,
+		Server:         Server{Address: serverURL},
+	})
+}
+
+// BuildConfigFromOverrides loads the iamconfig with the default loading rules, or
+// from overrides.IAMConfig if set, and applies overrides on top of it. It is meant
+// to be used with BindOverrideFlags.
+func BuildConfigFromOverrides(overrides *ConfigOverrides) (*restclient.Config, error) {
+	loadingRules := NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = overrides.IAMConfig
 
-	return directClientConfig.ClientConfig()
+	return NewDeferredLoadingClientConfigWithOverrides(loadingRules, overrides).ClientConfig()
 }
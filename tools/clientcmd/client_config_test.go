@@ -0,0 +1,116 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const multiContextConfig = `
+apiVersion: v1
+current-context: dev
+servers:
+  dev:
+    address: https://dev.iam.example.com
+  prod:
+    address: https://iam.example.com
+    timeout: 10s
+users:
+  developer:
+    token: dev-token
+  admin:
+    secret-id: id
+    secret-key: key
+contexts:
+  dev:
+    server: dev
+    user: developer
+  prod:
+    server: prod
+    user: admin
+  broken:
+    server: staging
+    user: nobody
+`
+
+func TestClientConfigContexts(t *testing.T) {
+	config, err := Load([]byte(multiContextConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		context     string
+		wantHost    string
+		wantToken   string
+		wantSecret  string
+		wantErr     bool
+		notFoundErr bool
+	}{
+		{name: "current context", wantHost: "https://dev.iam.example.com", wantToken: "dev-token"},
+		{name: "named context", context: "prod", wantHost: "https://iam.example.com", wantSecret: "id"},
+		{name: "missing references", context: "broken", wantErr: true},
+		{name: "unknown context", context: "staging", wantErr: true, notFoundErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restConfig, err := NewNonInteractiveClientConfig(*config, tt.context).ClientConfig()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				if IsContextNotFound(err) != tt.notFoundErr {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if restConfig.Host != tt.wantHost || restConfig.BearerToken != tt.wantToken || restConfig.SecretID != tt.wantSecret {
+				t.Errorf("unexpected config: %+v", restConfig)
+			}
+		})
+	}
+}
+
+func TestBuildConfigFromFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(multiContextConfig), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restConfig, err := BuildConfigFromFlagsWithContext("https://localhost:8000", path, "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restConfig.Host != "https://localhost:8000" || restConfig.SecretKey != "key" {
+		t.Errorf("unexpected config: %+v", restConfig)
+	}
+
+	// the original format has a single server and user
+	legacy := "server:\n  address: https://iam.example.com\nuser:\n  token: token\n"
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restConfig, err = BuildConfigFromFlags("", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restConfig.Host != "https://iam.example.com" || restConfig.BearerToken != "token" {
+		t.Errorf("unexpected config: %+v", restConfig)
+	}
+}
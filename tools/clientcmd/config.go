@@ -0,0 +1,302 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defines the timing of the iamconfig file lock.
+var (
+	// LockTimeout is how long to wait for another process to release the lock
+	// of an iamconfig file.
+	LockTimeout = 5 * time.Second
+	// StaleLockAge is the age after which a lock is considered left behind by a
+	// process which died while holding it, and is removed. Modifications take far
+	// less, so a lock this old is not held anymore.
+	StaleLockAge = 30 * time.Second
+	// lockRetryInterval is how often the lock is tried while it is held.
+	lockRetryInterval = 10 * time.Millisecond
+)
+
+// Write serializes the config to yaml, as LatestAPIVersion. Empty single server and
+// user are omitted.
+func Write(config Config) ([]byte, error) {
+	config.APIVersion = LatestAPIVersion
+
+	if isEmptyServer(config.Server) {
+		config.Server = nil
+	}
+
+	if isEmptyAuthInfo(config.AuthInfo) {
+		config.AuthInfo = nil
+	}
+
+	return yaml.Marshal(&config)
+}
+
+// WriteToFile serializes the config to yaml and writes it to filename. The file is
+// replaced atomically and is only readable by its owner. Use ModifyConfig to update
+// a file which may be modified concurrently.
+func WriteToFile(config Config, filename string) error {
+	content, err := Write(config)
+	if err != nil {
+		return err
+	}
+
+	filename, err = resolveSymlinks(filename)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(filename, content)
+}
+
+// ModifyConfig loads the iamconfig file at filename, or an empty config if it does
+// not exist, calls modify with it and writes the result back. The file is locked
+// in the meantime, so concurrent modifications are not lost. The fields not
+// understood by this version are preserved.
+func ModifyConfig(filename string, modify func(config *Config) error) error {
+	// the links to a file share its lock
+	filename, err := resolveSymlinks(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := lockFile(filename); err != nil {
+		return err
+	}
+	defer unlockFile(filename)
+
+	config, err := LoadFromFile(filename)
+	if os.IsNotExist(err) {
+		config, err = NewConfig(), nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := modify(config); err != nil {
+		return err
+	}
+
+	return WriteToFile(*config, filename)
+}
+
+// SetCurrentContext sets the current context of the iamconfig file at filename.
+func SetCurrentContext(filename, contextName string) error {
+	return ModifyConfig(filename, func(config *Config) error {
+		if _, exists := config.Contexts[contextName]; !exists {
+			return &errContextNotFound{contextName}
+		}
+
+		config.CurrentContext = contextName
+
+		return nil
+	})
+}
+
+// SetServer sets the server named name of the iamconfig file at filename, or its
+// single server if name is empty. The fields of the existing server not understood
+// by this version are preserved.
+func SetServer(filename, name string, server Server) error {
+	return ModifyConfig(filename, func(config *Config) error {
+		existing := config.Server
+		if len(name) != 0 {
+			existing = config.Servers[name]
+		}
+
+		if existing != nil && server.Extensions == nil {
+			server.Extensions = existing.Extensions
+		}
+
+		if len(name) == 0 {
+			config.Server = &server
+		} else {
+			if config.Servers == nil {
+				config.Servers = map[string]*Server{}
+			}
+
+			config.Servers[name] = &server
+		}
+
+		return nil
+	})
+}
+
+// SetAuthInfo sets the credentials of the user named name of the iamconfig file at
+// filename, or of its single user if name is empty. The fields of the existing user
+// not understood by this version are preserved.
+func SetAuthInfo(filename, name string, authInfo AuthInfo) error {
+	return ModifyConfig(filename, func(config *Config) error {
+		existing := config.AuthInfo
+		if len(name) != 0 {
+			existing = config.AuthInfos[name]
+		}
+
+		if existing != nil && authInfo.Extensions == nil {
+			authInfo.Extensions = existing.Extensions
+		}
+
+		if len(name) == 0 {
+			config.AuthInfo = &authInfo
+		} else {
+			if config.AuthInfos == nil {
+				config.AuthInfos = map[string]*AuthInfo{}
+			}
+
+			config.AuthInfos[name] = &authInfo
+		}
+
+		return nil
+	})
+}
+
+// SetContext sets the context named name of the iamconfig file at filename.
+func SetContext(filename, name string, context Context) error {
+	return ModifyConfig(filename, func(config *Config) error {
+		if existing := config.Contexts[name]; existing != nil && context.Extensions == nil {
+			context.Extensions = existing.Extensions
+		}
+
+		if config.Contexts == nil {
+			config.Contexts = map[string]*Context{}
+		}
+
+		config.Contexts[name] = &context
+
+		return nil
+	})
+}
+
+// resolveSymlinks returns the path of the file filename links to, so that it is
+// written in place of the link. A file which does not exist yet is created at
+// filename.
+func resolveSymlinks(filename string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(filename)
+	if os.IsNotExist(err) {
+		return filename, nil
+	}
+
+	return resolved, err
+}
+
+// writeFileAtomically writes content to a temporary file next to filename and
+// renames it to filename, so readers never see a partially written file.
+func writeFileAtomically(filename string, content []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+// lockName returns the name of the lock file of filename.
+func lockName(filename string) string {
+	return filename + ".lock"
+}
+
+// lockFile creates the lock file of filename, waiting up to LockTimeout for
+// another process to remove it. A lock older than StaleLockAge is removed.
+func lockFile(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockName(filename), os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			return f.Close()
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+
+		if removeStaleLock(filename) {
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("unable to lock %q: %q exists, remove it if no other process is modifying the config",
+				filename, lockName(filename))
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// removeStaleLock removes the lock file of filename if it is older than
+// StaleLockAge, and returns whether the lock may be taken again.
+func removeStaleLock(filename string) bool {
+	name := lockName(filename)
+
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return true
+	}
+
+	if err != nil || time.Since(info.ModTime()) < StaleLockAge {
+		return false
+	}
+
+	// another process may replace the stale lock with its own after the stat:
+	// the lock is moved aside, which only one process can do, and removed only
+	// if it is still the stale one
+	moved := fmt.Sprintf("%s.stale-%d-%d", name, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(name, moved); err != nil {
+		return os.IsNotExist(err)
+	}
+	defer os.Remove(moved)
+
+	if movedInfo, err := os.Stat(moved); err != nil || !os.SameFile(info, movedInfo) {
+		// a live lock was moved, put it back unless the lock was taken since
+		_ = os.Link(moved, name)
+
+		return false
+	}
+
+	return true
+}
+
+// unlockFile removes the lock file of filename.
+func unlockFile(filename string) error {
+	return os.Remove(lockName(filename))
+}
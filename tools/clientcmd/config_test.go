@@ -0,0 +1,201 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestModifyConfigPreservesUnknownFields(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config", `
+kind: Config
+servers:
+  dev:
+    address: https://dev.iam.example.com
+    compression: gzip
+users:
+  dev:
+    token: old
+    future-field:
+      nested: true
+contexts:
+  dev:
+    server: dev
+    user: dev
+`)
+
+	if err := SetAuthInfo(path, "dev", AuthInfo{Token: "new"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := SetCurrentContext(path, "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"kind: Config", "compression: gzip", "nested: true", "token: new", "current-context: dev"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in the written config:\n%s", want, data)
+		}
+	}
+
+	if strings.Contains(string(data), "LocationOfOrigin") {
+		t.Errorf("unexpected LocationOfOrigin in the written config:\n%s", data)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected mode 0600, got %v", perm)
+	}
+}
+
+func TestModifyConfigNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".iam", "config")
+
+	if err := SetServer(path, "", Server{Address: "https://iam.example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Server.Address != "https://iam.example.com" {
+		t.Errorf("unexpected server: %+v", config.Server)
+	}
+
+	if err := SetCurrentContext(path, "missing"); !IsContextNotFound(err) {
+		t.Errorf("expected context not found, got %v", err)
+	}
+
+	if _, err := os.Stat(lockName(path)); !os.IsNotExist(err) {
+		t.Errorf("expected the lock to be released, got %v", err)
+	}
+}
+
+func TestModifyConfigConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := SetServer(path, name, Server{Address: "https://" + name}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	config, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(config.Servers) != 5 {
+		t.Errorf("expected 5 servers, got %v", config.Servers)
+	}
+}
+
+func TestModifyConfigNilMaps(t *testing.T) {
+	// the maps are decoded as nil
+	path := writeConfig(t, t.TempDir(), "config", "servers:\nusers:\ncontexts:\n")
+
+	if err := SetServer(path, "dev", Server{Address: "https://dev.iam.example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := SetAuthInfo(path, "dev", AuthInfo{Token: "token"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := SetContext(path, "dev", Context{Server: "dev", AuthInfo: "dev"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(config.Servers) != 1 || len(config.AuthInfos) != 1 || len(config.Contexts) != 1 {
+		t.Errorf("unexpected config: %+v", config)
+	}
+}
+
+func TestModifyConfigStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	if err := os.WriteFile(lockName(path), nil, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	timeout := LockTimeout
+	LockTimeout = 50 * time.Millisecond
+	defer func() { LockTimeout = timeout }()
+
+	// a lock held by another process is honored
+	if err := SetServer(path, "", Server{Address: "https://iam.example.com"}); err == nil {
+		t.Fatal("expected the lock to be held")
+	}
+
+	// a process died while holding the lock
+	stale := time.Now().Add(-2 * StaleLockAge)
+	if err := os.Chtimes(lockName(path), stale, stale); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := SetServer(path, "", Server{Address: "https://iam.example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(lockName(path)); !os.IsNotExist(err) {
+		t.Errorf("expected the lock to be released, got %v", err)
+	}
+}
+
+func TestModifyConfigSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := writeConfig(t, dir, "config", "server:\n  address: https://old.iam.example.com\n")
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := SetServer(link, "", Server{Address: "https://iam.example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the link to be kept, got %v (%v)", info, err)
+	}
+
+	config, err := LoadFromFile(target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Server.Address != "https://iam.example.com" {
+		t.Errorf("expected the linked file to be written, got %+v", config.Server)
+	}
+}
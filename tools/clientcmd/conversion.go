@@ -0,0 +1,195 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defines the versions of the iamconfig schema.
+const (
+	// APIVersionV1 is the original schema, with a single server and user. The files
+	// without apiVersion use it.
+	APIVersionV1 = "v1"
+	// APIVersionV2 no longer serializes the location of origin of the entries.
+	APIVersionV2 = "v2"
+	// LatestAPIVersion is the version the iamconfig files are converted to when they
+	// are loaded, and the version of the written files.
+	LatestAPIVersion = APIVersionV2
+)
+
+// conversion upgrades an iamconfig document to the version To.
+type conversion struct {
+	To      string
+	Convert func(document *yaml.Node) error
+}
+
+// conversions are the conversions of the iamconfig documents by source version.
+// Following them from any version leads to LatestAPIVersion.
+var conversions = map[string]conversion{
+	APIVersionV1: {To: APIVersionV2, Convert: convertV1ToV2},
+}
+
+// errUnsupportedVersion is returned for the iamconfig versions this package does
+// not know, e.g. written by a newer release.
+type errUnsupportedVersion struct {
+	APIVersion string
+}
+
+func (e *errUnsupportedVersion) Error() string {
+	return fmt.Sprintf("iamconfig apiVersion %q is not supported, the latest supported version is %q: "+
+		"it may have been written by a newer release", e.APIVersion, LatestAPIVersion)
+}
+
+// IsUnsupportedVersion returns whether err reports an iamconfig version which is
+// not supported by this release.
+func IsUnsupportedVersion(err error) bool {
+	var unsupported *errUnsupportedVersion
+	return errors.As(err, &unsupported)
+}
+
+// parseDocument parses data and converts it to LatestAPIVersion. It returns the
+// mapping node of the document, nil when data holds no document, and the version
+// of data.
+func parseDocument(data []byte) (*yaml.Node, string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, "", err
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil, LatestAPIVersion, nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, "", fmt.Errorf("line %d: iamconfig must be a mapping", root.Line)
+	}
+
+	version, err := convertToLatest(root)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return root, version, nil
+}
+
+// convertToLatest converts the iamconfig document to LatestAPIVersion and returns
+// its original version.
+func convertToLatest(document *yaml.Node) (string, error) {
+	original := APIVersionV1
+	if value := mappingValue(document, "apiVersion"); value != nil && len(value.Value) != 0 {
+		original = value.Value
+	}
+
+	for version := original; version != LatestAPIVersion; {
+		next, exists := conversions[version]
+		if !exists {
+			return "", &errUnsupportedVersion{original}
+		}
+
+		if err := next.Convert(document); err != nil {
+			return "", fmt.Errorf("unable to convert iamconfig from %s to %s: %w", version, next.To, err)
+		}
+
+		version = next.To
+	}
+
+	if original != LatestAPIVersion {
+		setMappingValue(document, "apiVersion", LatestAPIVersion)
+	}
+
+	return original, nil
+}
+
+// convertV1ToV2 removes the location of origin of the single server and user, v1
+// wrote it along with them.
+func convertV1ToV2(document *yaml.Node) error {
+	for _, key := range []string{"server", "user"} {
+		if entry := mappingValue(document, key); entry != nil && entry.Kind == yaml.MappingNode {
+			deleteMappingKey(entry, "locationoforigin")
+		}
+	}
+
+	return nil
+}
+
+// MigrateFile converts the iamconfig file at filename to LatestAPIVersion on disk,
+// keeping its comments, and returns whether it was converted. The original file is
+// kept next to it, with its version as suffix, e.g. config.v1.
+func MigrateFile(filename string) (bool, error) {
+	if _, err := os.Stat(filename); err != nil {
+		return false, err
+	}
+
+	if err := lockFile(filename); err != nil {
+		return false, err
+	}
+	defer unlockFile(filename)
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+
+	document, version, err := parseDocument(data)
+	if err != nil || version == LatestAPIVersion {
+		return false, err
+	}
+
+	converted, err := yaml.Marshal(document)
+	if err != nil {
+		return false, err
+	}
+
+	if err := writeFileAtomically(filename+"."+strings.ReplaceAll(version, "/", "-"), data); err != nil {
+		return false, err
+	}
+
+	if err := writeFileAtomically(filename, converted); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// mappingValue returns the value of key in the mapping node, nil if it is not set.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// setMappingValue sets key to the scalar value in the mapping node.
+func setMappingValue(node *yaml.Node, key, value string) {
+	if existing := mappingValue(node, key); existing != nil {
+		*existing = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Line: existing.Line, Column: existing.Column}
+		return
+	}
+
+	node.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	}, node.Content...)
+}
+
+// deleteMappingKey removes key from the mapping node.
+func deleteMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const v1Config = `# written by an older release
+server:
+  locationoforigin: /home/dev/.iam/config
+  address: https://iam.example.com
+user:
+  locationoforigin: /home/dev/.iam/config
+  token: abc
+`
+
+func TestLoadConvertsOlderVersions(t *testing.T) {
+	config, err := LoadStrict([]byte(v1Config))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.APIVersion != LatestAPIVersion {
+		t.Errorf("expected version %q, got %q", LatestAPIVersion, config.APIVersion)
+	}
+
+	if len(config.Server.Extensions) != 0 || len(config.AuthInfo.Extensions) != 0 {
+		t.Errorf("expected the location of origin to be dropped, got %v and %v",
+			config.Server.Extensions, config.AuthInfo.Extensions)
+	}
+
+	if config.Server.Address != "https://iam.example.com" || config.AuthInfo.Token != "abc" {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
+
+func TestLoadUnsupportedVersion(t *testing.T) {
+	_, err := Load([]byte("apiVersion: v9\nserver:\n  address: https://iam.example.com\n"))
+	if !IsUnsupportedVersion(err) {
+		t.Errorf("expected an unsupported version error, got %v", err)
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config", v1Config)
+
+	migrated, err := MigrateFile(path)
+	if err != nil || !migrated {
+		t.Fatalf("expected the file to be migrated, got %v, %v", migrated, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if content := string(data); !strings.Contains(content, "# written by an older release") ||
+		!strings.Contains(content, "apiVersion: "+LatestAPIVersion) || strings.Contains(content, "locationoforigin") {
+		t.Errorf("unexpected migrated file:\n%s", content)
+	}
+
+	if backup, err := os.ReadFile(path + "." + APIVersionV1); err != nil || string(backup) != v1Config {
+		t.Errorf("expected the original file to be kept, got %q, %v", backup, err)
+	}
+
+	if migrated, err := MigrateFile(path); err != nil || migrated {
+		t.Errorf("expected the file to be up to date, got %v, %v", migrated, err)
+	}
+}
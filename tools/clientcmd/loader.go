@@ -5,10 +5,12 @@
 package clientcmd
 
 import (
+	"fmt"
 	"os"
 	"path"
-
-	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"reflect"
+	"slices"
 
 	"github.com/coding-hui/common/util/homedir"
 )
@@ -19,32 +21,222 @@ const (
 	RecommendedConfigPathEnvVar = "IAMCONFIG"
 	RecommendedHomeDir          = ".iam"
 	RecommendedFileName         = "config"
-	RecommendedSchemaName       = "scheme"
 )
 
 // Defines some useful variables.
 var (
-	RecommendedConfigDir  = path.Join(homedir.HomeDir(), RecommendedHomeDir)
-	RecommendedHomeFile   = path.Join(RecommendedConfigDir, RecommendedFileName)
-	RecommendedSchemaFile = path.Join(RecommendedConfigDir, RecommendedSchemaName)
+	RecommendedConfigDir = path.Join(homedir.HomeDir(), RecommendedHomeDir)
+	RecommendedHomeFile  = path.Join(RecommendedConfigDir, RecommendedFileName)
 )
 
+// Defines the environment variables read when no iamconfig file configures a server.
+const (
+	EnvServerAddress = "IAM_SERVER_ADDRESS"
+	EnvToken         = "IAM_TOKEN"
+	EnvUsername      = "IAM_USERNAME"
+	EnvPassword      = "IAM_PASSWORD"
+	EnvSecretID      = "IAM_SECRET_ID"
+	EnvSecretKey     = "IAM_SECRET_KEY"
+)
+
+// EnvironmentLocation is the LocationOfOrigin of the server and user read from the
+// environment variables.
+const EnvironmentLocation = "environment"
+
+// ClientConfigLoadingRules is an ExplicitPath and string slice of specific locations
+// that are used for merging together a Config. Callers can put the chain together
+// however they want, but we'd recommend:
+// ExplicitPath, IAMCONFIG paths, RecommendedHomeFile, environment variables.
+type ClientConfigLoadingRules struct {
+	// ExplicitPath is the only file loaded when set, it must exist.
+	ExplicitPath string
+	// Precedence are the files merged when ExplicitPath is not set, missing files
+	// are ignored.
+	Precedence []string
+	// DisableEnvironment disables the fallback to the environment variables.
+	DisableEnvironment bool
+	// Strict rejects the files with unknown fields or invalid values, see LoadStrict.
+	Strict bool
+	// Migrate converts the files of older versions to LatestAPIVersion on disk
+	// when they are loaded, see MigrateFile.
+	Migrate bool
+}
+
+// NewDefaultClientConfigLoadingRules returns a ClientConfigLoadingRules object with
+// default fields filled in: the colon-separated (semicolon on Windows) list of files
+// of the IAMCONFIG environment variable if set, otherwise RecommendedHomeFile.
+func NewDefaultClientConfigLoadingRules() *ClientConfigLoadingRules {
+	chain := []string{}
+
+	if envVarFiles := os.Getenv(RecommendedConfigPathEnvVar); len(envVarFiles) != 0 {
+		for _, file := range filepath.SplitList(envVarFiles) {
+			if len(file) != 0 && !slices.Contains(chain, file) {
+				chain = append(chain, file)
+			}
+		}
+	} else {
+		chain = append(chain, RecommendedHomeFile)
+	}
+
+	return &ClientConfigLoadingRules{
+		Precedence: chain,
+	}
+}
+
+// GetLoadingPrecedence returns the files loaded, in order of precedence.
+func (rules *ClientConfigLoadingRules) GetLoadingPrecedence() []string {
+	if len(rules.ExplicitPath) != 0 {
+		return []string{rules.ExplicitPath}
+	}
+
+	return rules.Precedence
+}
+
+// Load takes the loading rules and returns a Config object based on the following rules:
+//
+//   - if the ExplicitPath is set, only this file is loaded and it must exist.
+//   - otherwise the Precedence files are merged: the first file to set a server,
+//     user or context of a given name, the current-context, or the single server
+//     or user wins; the later files can only add new entries.
+//   - when the merged config configures no server and chooses no context, the
+//     single server and user are read from the environment variables.
+//
+// The LocationOfOrigin of every server, user and context is the file it comes from,
+// or EnvironmentLocation.
+func (rules *ClientConfigLoadingRules) Load() (*Config, error) {
+	config := NewConfig()
+
+	for _, filename := range rules.GetLoadingPrecedence() {
+		if rules.Migrate {
+			if _, err := MigrateFile(filename); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("error migrating config file %q: %w", filename, err)
+			}
+		}
+
+		load := Load
+		if rules.Strict {
+			load = LoadStrict
+		}
+
+		fileConfig, err := loadFromFile(filename, load)
+		if err != nil {
+			if os.IsNotExist(err) && len(rules.ExplicitPath) == 0 {
+				continue
+			}
+
+			return nil, fmt.Errorf("error loading config file %q: %w", filename, err)
+		}
+
+		mergeConfig(config, fileConfig)
+	}
+
+	if !rules.DisableEnvironment && len(config.CurrentContext) == 0 && isEmptyServer(config.Server) {
+		mergeEnvironment(config)
+	}
+
+	return config, nil
+}
+
+// mergeConfig adds to config the entries of other it does not have yet.
+func mergeConfig(config, other *Config) {
+	if len(config.APIVersion) == 0 {
+		config.APIVersion = other.APIVersion
+	}
+
+	if len(config.CurrentContext) == 0 {
+		config.CurrentContext = other.CurrentContext
+	}
+
+	for name, server := range other.Servers {
+		if _, exists := config.Servers[name]; !exists {
+			config.Servers[name] = server
+		}
+	}
+
+	for name, authInfo := range other.AuthInfos {
+		if _, exists := config.AuthInfos[name]; !exists {
+			config.AuthInfos[name] = authInfo
+		}
+	}
+
+	for name, context := range other.Contexts {
+		if _, exists := config.Contexts[name]; !exists {
+			config.Contexts[name] = context
+		}
+	}
+
+	if isEmptyServer(config.Server) && !isEmptyServer(other.Server) {
+		config.Server = other.Server
+	}
+
+	if isEmptyAuthInfo(config.AuthInfo) && !isEmptyAuthInfo(other.AuthInfo) {
+		config.AuthInfo = other.AuthInfo
+	}
+}
+
+// mergeEnvironment sets the single server and user of config from the environment
+// variables, if they are set.
+func mergeEnvironment(config *Config) {
+	if address := os.Getenv(EnvServerAddress); len(address) != 0 {
+		config.Server = &Server{
+			LocationOfOrigin: EnvironmentLocation,
+			Address:          address,
+		}
+	}
+
+	authInfo := &AuthInfo{
+		LocationOfOrigin: EnvironmentLocation,
+		Token:            os.Getenv(EnvToken),
+		Username:         os.Getenv(EnvUsername),
+		Password:         os.Getenv(EnvPassword),
+		SecretID:         os.Getenv(EnvSecretID),
+		SecretKey:        os.Getenv(EnvSecretKey),
+	}
+
+	if isEmptyAuthInfo(config.AuthInfo) && !isEmptyAuthInfo(authInfo) {
+		config.AuthInfo = authInfo
+	}
+}
+
+// isEmptyAuthInfo returns whether authInfo is not configured.
+func isEmptyAuthInfo(authInfo *AuthInfo) bool {
+	if authInfo == nil {
+		return true
+	}
+
+	// where the user was loaded from is not part of its configuration
+	empty := *authInfo
+	empty.LocationOfOrigin = ""
+
+	if len(empty.Extensions) == 0 {
+		empty.Extensions = nil
+	}
+
+	return reflect.DeepEqual(empty, AuthInfo{})
+}
+
 // LoadFromFile load config from file.
 func LoadFromFile(filename string) (*Config, error) {
+	return loadFromFile(filename, Load)
+}
+
+// LoadFromFileStrict is like LoadFromFile but decodes the file with LoadStrict.
+func LoadFromFileStrict(filename string) (*Config, error) {
+	return loadFromFile(filename, LoadStrict)
+}
+
+// loadFromFile decodes the file with load and sets the LocationOfOrigin of its entries.
+func loadFromFile(filename string, load func(data []byte) (*Config, error)) (*Config, error) {
 	iamconfigBytes, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	config, err := Load(iamconfigBytes)
+	config, err := load(iamconfigBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	// set LocationOfOrigin on every Cluster, User, and Context
-	config.AuthInfo.LocationOfOrigin = filename
-	config.Server.LocationOfOrigin = filename
-
 	if config.AuthInfo == nil {
 		config.AuthInfo = &AuthInfo{}
 	}
@@ -53,11 +245,44 @@ func LoadFromFile(filename string) (*Config, error) {
 		config.Server = &Server{}
 	}
 
+	// set LocationOfOrigin on every Server, User, and Context
+	config.AuthInfo.LocationOfOrigin = filename
+	config.Server.LocationOfOrigin = filename
+
+	for name, server := range config.Servers {
+		if server == nil {
+			server = &Server{}
+			config.Servers[name] = server
+		}
+
+		server.LocationOfOrigin = filename
+	}
+
+	for name, authInfo := range config.AuthInfos {
+		if authInfo == nil {
+			authInfo = &AuthInfo{}
+			config.AuthInfos[name] = authInfo
+		}
+
+		authInfo.LocationOfOrigin = filename
+	}
+
+	for name, context := range config.Contexts {
+		if context == nil {
+			context = &Context{}
+			config.Contexts[name] = context
+		}
+
+		context.LocationOfOrigin = filename
+	}
+
 	return config, nil
 }
 
 // Load takes a byte slice and deserializes the contents into Config object.
-// Encapsulates deserialization without assuming the source is a file.
+// Encapsulates deserialization without assuming the source is a file. The contents
+// of older versions are converted to LatestAPIVersion, the unknown versions are
+// rejected, see IsUnsupportedVersion.
 func Load(data []byte) (*Config, error) {
 	config := NewConfig()
 	// if there's no data in a file, return the default object instead of failing (DecodeInto reject empty input)
@@ -65,7 +290,12 @@ func Load(data []byte) (*Config, error) {
 		return config, nil
 	}
 
-	if err := yaml.Unmarshal(data, config); err != nil {
+	document, _, err := parseDocument(data)
+	if err != nil || document == nil {
+		return config, err
+	}
+
+	if err := document.Decode(config); err != nil {
 		return nil, err
 	}
 
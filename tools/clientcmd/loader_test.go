@@ -0,0 +1,114 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadingRulesPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	first := writeConfig(t, dir, "first", `
+current-context: dev
+servers:
+  dev:
+    address: https://dev.iam.example.com
+contexts:
+  dev:
+    server: dev
+`)
+	second := writeConfig(t, dir, "second", `
+current-context: prod
+servers:
+  dev:
+    address: https://other.iam.example.com
+  prod:
+    address: https://iam.example.com
+contexts:
+  prod:
+    server: prod
+`)
+	missing := filepath.Join(dir, "missing")
+
+	t.Setenv(RecommendedConfigPathEnvVar, strings.Join([]string{first, missing, second, first}, string(filepath.ListSeparator)))
+
+	rules := NewDefaultClientConfigLoadingRules()
+	if got := rules.GetLoadingPrecedence(); len(got) != 3 {
+		t.Fatalf("expected duplicated files to be dropped, got %v", got)
+	}
+
+	config, err := rules.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.CurrentContext != "dev" {
+		t.Errorf("expected the first current-context to win, got %q", config.CurrentContext)
+	}
+
+	if server := config.Servers["dev"]; server.Address != "https://dev.iam.example.com" || server.LocationOfOrigin != first {
+		t.Errorf("expected the first dev server to win, got %+v", server)
+	}
+
+	if server := config.Servers["prod"]; server == nil || server.LocationOfOrigin != second {
+		t.Errorf("expected the prod server to be merged from %q, got %+v", second, server)
+	}
+
+	if context := config.Contexts["prod"]; context == nil || context.LocationOfOrigin != second {
+		t.Errorf("expected the prod context to be merged from %q, got %+v", second, context)
+	}
+
+	rules.ExplicitPath = missing
+	if _, err := rules.Load(); err == nil {
+		t.Error("expected an error for a missing explicit path")
+	}
+}
+
+func TestLoadingRulesEnvironment(t *testing.T) {
+	t.Setenv(RecommendedConfigPathEnvVar, filepath.Join(t.TempDir(), "missing"))
+	t.Setenv(EnvServerAddress, "https://iam.example.com")
+	t.Setenv(EnvSecretID, "id")
+	t.Setenv(EnvSecretKey, "key")
+
+	restConfig, err := NewNonInteractiveDeferredLoadingClientConfig(NewDefaultClientConfigLoadingRules(), "").ClientConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restConfig.Host != "https://iam.example.com" || restConfig.SecretID != "id" || restConfig.SecretKey != "key" {
+		t.Errorf("unexpected config: %+v", restConfig)
+	}
+
+	// the environment is ignored when a file configures a server
+	path := writeConfig(t, t.TempDir(), "config", "server:\n  address: https://file.iam.example.com\n")
+	t.Setenv(RecommendedConfigPathEnvVar, path)
+
+	config, err := NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Server.Address != "https://file.iam.example.com" || config.Server.LocationOfOrigin != path {
+		t.Errorf("unexpected server: %+v", config.Server)
+	}
+
+	if config.AuthInfo.LocationOfOrigin == EnvironmentLocation {
+		t.Errorf("expected the environment user to be ignored, got %+v", config.AuthInfo)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"sync"
+
+	restclient "github.com/coding-hui/wecoding-sdk-go/rest"
+)
+
+// DeferredLoadingClientConfig is a ClientConfig interface that is backed by a
+// client config loader. The config is loaded once, when it is first needed, so
+// the loading rules may still be changed after it is instantiated.
+type DeferredLoadingClientConfig struct {
+	loader    *ClientConfigLoadingRules
+	overrides *ConfigOverrides
+
+	loadClientConfig sync.Once
+	clientConfig     ClientConfig
+	err              error
+}
+
+var _ ClientConfig = &DeferredLoadingClientConfig{}
+
+// NewNonInteractiveDeferredLoadingClientConfig creates a ClientConfig using the
+// context named contextName of the config loaded by loader. An empty contextName
+// means the current context.
+func NewNonInteractiveDeferredLoadingClientConfig(loader *ClientConfigLoadingRules, contextName string) ClientConfig {
+	return &DeferredLoadingClientConfig{loader: loader, overrides: &ConfigOverrides{CurrentContext: contextName}}
+}
+
+// NewDeferredLoadingClientConfigWithOverrides creates a ClientConfig using the config
+// loaded by loader with overrides applied on top of it. The overrides are read when
+// the config is loaded, so they may still be set, e.g. by parsing flags, after it
+// is instantiated.
+func NewDeferredLoadingClientConfigWithOverrides(loader *ClientConfigLoadingRules, overrides *ConfigOverrides) ClientConfig {
+	return &DeferredLoadingClientConfig{loader: loader, overrides: overrides}
+}
+
+// createClientConfig loads the config and wraps it in a ClientConfig.
+func (config *DeferredLoadingClientConfig) createClientConfig() (ClientConfig, error) {
+	config.loadClientConfig.Do(func() {
+		var rawConfig *Config
+
+		rawConfig, config.err = config.loader.Load()
+		if config.err == nil {
+			config.clientConfig = NewClientConfigWithOverrides(*rawConfig, config.overrides)
+		}
+	})
+
+	return config.clientConfig, config.err
+}
+
+// ClientConfig implements ClientConfig.
+func (config *DeferredLoadingClientConfig) ClientConfig() (*restclient.Config, error) {
+	clientConfig, err := config.createClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return clientConfig.ClientConfig()
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+)
+
+// Names of the flags registered by BindOverrideFlags.
+const (
+	FlagIAMConfig        = "iamconfig"
+	FlagContext          = "context"
+	FlagServer           = "server"
+	FlagToken            = "token"
+	FlagSecretID         = "secret-id"
+	FlagInsecure         = "insecure-skip-tls-verify"
+	FlagCAFile           = "certificate-authority"
+	FlagProxyURL         = "proxy-url"
+	FlagReloadTLSFiles   = "reload-tls-files"
+	FlagTimeout          = "request-timeout"
+	FlagDebug            = "http-debug"
+	FlagCurl             = "http-curl"
+	FlagImpersonate      = "as"
+	FlagImpersonateGroup = "as-group"
+)
+
+// ConfigOverrides holds values which take precedence over the loaded iamconfig,
+// usually set from command line flags. Empty fields do not override anything.
+type ConfigOverrides struct {
+	// IAMConfig is the path of the iamconfig file to load, see ClientConfigLoadingRules.ExplicitPath.
+	IAMConfig string
+	// CurrentContext is the name of the context to use instead of the current context.
+	CurrentContext string
+	// Server overrides the fields of the server of the chosen context.
+	Server Server
+	// AuthInfo overrides the fields of the user of the chosen context. Setting any
+	// credential replaces the credentials of the user.
+	AuthInfo AuthInfo
+	// Timeout is the request timeout, in the format accepted by ParseTimeout.
+	Timeout string
+	// Debug configures the debug logs of the requests.
+	Debug transport.DebugConfig
+}
+
+// BindOverrideFlags registers the flags setting overrides on flags.
+// Use BuildConfigFromOverrides to build a client config once they are parsed.
+func BindOverrideFlags(overrides *ConfigOverrides, flags *flag.FlagSet) {
+	flags.StringVar(&overrides.IAMConfig, FlagIAMConfig, overrides.IAMConfig,
+		"Path to the iamconfig file to use for CLI requests.")
+	flags.StringVar(&overrides.CurrentContext, FlagContext, overrides.CurrentContext,
+		"The name of the iamconfig context to use")
+	flags.StringVar(&overrides.Server.Address, FlagServer, overrides.Server.Address,
+		"The address of the iam API server")
+	flags.StringVar(&overrides.AuthInfo.Token, FlagToken, overrides.AuthInfo.Token,
+		"Bearer token for authentication to the API server")
+	flags.StringVar(&overrides.AuthInfo.SecretID, FlagSecretID, overrides.AuthInfo.SecretID,
+		"Secret ID for authentication to the API server, used with the secret key of the iamconfig")
+	flags.BoolVar(&overrides.Server.InsecureSkipTLSVerify, FlagInsecure, overrides.Server.InsecureSkipTLSVerify,
+		"If true, the server's certificate will not be checked for validity. This will make your HTTPS connections insecure")
+	flags.StringVar(&overrides.Server.CertificateAuthority, FlagCAFile, overrides.Server.CertificateAuthority,
+		"Path to a cert file for the certificate authority")
+	flags.StringVar(&overrides.Server.ProxyURL, FlagProxyURL, overrides.Server.ProxyURL,
+		"URL of the proxy to send the requests through, e.g. socks5://localhost:1080")
+	flags.BoolVar(&overrides.Server.ReloadTLSFiles, FlagReloadTLSFiles, overrides.Server.ReloadTLSFiles,
+		"If true, the certificate authority and client certificate files are read again when they change")
+	flags.StringVar(&overrides.Timeout, FlagTimeout, overrides.Timeout,
+		"The length of time to wait before giving up on a single server request. Non-zero values should contain "+
+			"a corresponding time unit (e.g. 1s, 2m, 3h). A value of zero means don't timeout requests.")
+	flags.StringVar(&overrides.AuthInfo.Impersonate, FlagImpersonate, overrides.AuthInfo.Impersonate,
+		"Username to impersonate for the operation")
+	flags.Var((*stringSliceValue)(&overrides.AuthInfo.ImpersonateGroups), FlagImpersonateGroup,
+		"Group to impersonate for the operation, this flag can be repeated to specify multiple groups")
+	flags.IntVar((*int)(&overrides.Debug.Level), FlagDebug, int(overrides.Debug.Level),
+		"Verbosity of the debug logs of the requests: 1 logs their URL, status and latency, 2 their headers "+
+			"and 3 their bodies, with the credentials redacted")
+	flags.BoolVar(&overrides.Debug.CurlCommand, FlagCurl, overrides.Debug.CurlCommand,
+		"If true, a curl command equivalent to every request is logged, with the credentials redacted")
+}
+
+// mergeServer applies the server overrides to server.
+func (o *ConfigOverrides) mergeServer(server *Server) {
+	overrides := o.Server
+
+	if len(overrides.Address) != 0 {
+		server.Address = overrides.Address
+	}
+
+	if len(overrides.TLSServerName) != 0 {
+		server.TLSServerName = overrides.TLSServerName
+	}
+
+	if len(overrides.ProxyURL) != 0 {
+		server.ProxyURL = overrides.ProxyURL
+	}
+
+	if overrides.ReloadTLSFiles {
+		server.ReloadTLSFiles = true
+	}
+
+	if overrides.Timeout != 0 {
+		server.Timeout = overrides.Timeout
+	}
+
+	if overrides.MaxRetries != 0 {
+		server.MaxRetries = overrides.MaxRetries
+	}
+
+	if overrides.RetryInterval != 0 {
+		server.RetryInterval = overrides.RetryInterval
+	}
+
+	// a certificate authority of the overrides replaces the one of the server
+	if len(overrides.CertificateAuthority) != 0 || len(overrides.CertificateAuthorityData) != 0 {
+		server.CertificateAuthority = overrides.CertificateAuthority
+		server.CertificateAuthorityData = overrides.CertificateAuthorityData
+	}
+
+	if overrides.InsecureSkipTLSVerify {
+		server.InsecureSkipTLSVerify = true
+
+		// the certificate authority of the server can't be combined with insecure
+		if len(overrides.CertificateAuthority) == 0 && len(overrides.CertificateAuthorityData) == 0 {
+			server.CertificateAuthority = ""
+			server.CertificateAuthorityData = ""
+		}
+	}
+}
+
+// mergeAuthInfo applies the user overrides to authInfo. Credentials set in the
+// overrides replace all the credentials of authInfo, so they don't conflict.
+func (o *ConfigOverrides) mergeAuthInfo(authInfo *AuthInfo) {
+	overrides := o.AuthInfo
+
+	switch {
+	case len(overrides.Token) != 0:
+		clearCredentials(authInfo)
+		authInfo.Token = overrides.Token
+	case len(overrides.SecretID) != 0:
+		secretKey := authInfo.SecretKey
+		if len(overrides.SecretKey) != 0 {
+			secretKey = overrides.SecretKey
+		}
+
+		clearCredentials(authInfo)
+		authInfo.SecretID = overrides.SecretID
+		authInfo.SecretKey = secretKey
+	case len(overrides.Username) != 0:
+		clearCredentials(authInfo)
+		authInfo.Username = overrides.Username
+		authInfo.Password = overrides.Password
+	case overrides.Exec != nil:
+		clearCredentials(authInfo)
+		authInfo.Exec = overrides.Exec
+	}
+
+	// the impersonated user of the overrides replaces the one of authInfo
+	if len(overrides.Impersonate) != 0 {
+		authInfo.Impersonate = overrides.Impersonate
+		authInfo.ImpersonateGroups = overrides.ImpersonateGroups
+		authInfo.ImpersonateUserExtra = overrides.ImpersonateUserExtra
+	}
+
+	if len(overrides.ClientCertificate) != 0 || len(overrides.ClientCertificateData) != 0 {
+		authInfo.ClientCertificate = overrides.ClientCertificate
+		authInfo.ClientCertificateData = overrides.ClientCertificateData
+	}
+
+	if len(overrides.ClientKey) != 0 || len(overrides.ClientKeyData) != 0 {
+		authInfo.ClientKey = overrides.ClientKey
+		authInfo.ClientKeyData = overrides.ClientKeyData
+	}
+}
+
+// clearCredentials removes the credentials of authInfo, but not its client certificate.
+func clearCredentials(authInfo *AuthInfo) {
+	authInfo.Token = ""
+	authInfo.Username = ""
+	authInfo.Password = ""
+	authInfo.SecretID = ""
+	authInfo.SecretKey = ""
+	authInfo.Exec = nil
+}
+
+// stringSliceValue is a flag.Value appending every value of a repeated flag.
+type stringSliceValue []string
+
+// String implements flag.Value.
+func (s *stringSliceValue) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set implements flag.Value.
+func (s *stringSliceValue) Set(value string) error {
+	*s = append(*s, value)
+
+	return nil
+}
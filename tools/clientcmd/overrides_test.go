@@ -0,0 +1,131 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"flag"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/transport"
+)
+
+func TestBindOverrideFlags(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config", `
+current-context: dev
+servers:
+  dev:
+    address: https://dev.iam.example.com
+    timeout: 5s
+    certificate-authority-data: ca
+users:
+  dev:
+    username: admin
+    password: secret
+contexts:
+  dev:
+    server: dev
+    user: dev
+`)
+
+	overrides := &ConfigOverrides{}
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindOverrideFlags(overrides, flags)
+
+	err := flags.Parse([]string{
+		"--iamconfig", path,
+		"--server", "https://iam.example.com",
+		"--token", "abc",
+		"--insecure-skip-tls-verify",
+		"--request-timeout", "30",
+		"--proxy-url", "socks5://proxy.example.com:1080",
+		"--reload-tls-files",
+		"--http-debug", "2",
+		"--as", "alice",
+		"--as-group", "admins",
+		"--as-group", "developers",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, err := BuildConfigFromOverrides(overrides)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Host != "https://iam.example.com" {
+		t.Errorf("unexpected host %q", config.Host)
+	}
+
+	if config.BearerToken != "abc" || len(config.Username) != 0 || len(config.Password) != 0 {
+		t.Errorf("expected the token to replace the credentials, got %+v", config)
+	}
+
+	if !config.Insecure || len(config.CAData) != 0 {
+		t.Errorf("expected an insecure config without CA, got %+v", config.TLSClientConfig)
+	}
+
+	if !config.ReloadTLSFiles {
+		t.Error("expected the TLS files to be reloaded")
+	}
+
+	if config.Timeout != 30*time.Second {
+		t.Errorf("unexpected timeout %v", config.Timeout)
+	}
+
+	impersonate := transport.ImpersonationConfig{UserName: "alice", Groups: []string{"admins", "developers"}}
+	if !reflect.DeepEqual(config.Impersonate, impersonate) {
+		t.Errorf("expected to impersonate %+v, got %+v", impersonate, config.Impersonate)
+	}
+
+	if config.Debug.Level != transport.DebugHeaders {
+		t.Errorf("unexpected debug level %v", config.Debug.Level)
+	}
+
+	if config.Proxy == nil {
+		t.Fatal("expected a proxy")
+	}
+
+	proxy, _ := config.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "iam.example.com"}})
+	if proxy == nil || proxy.String() != "socks5://proxy.example.com:1080" {
+		t.Errorf("unexpected proxy %v", proxy)
+	}
+}
+
+func TestConfigOverridesKeepConfig(t *testing.T) {
+	config := NewConfig()
+	config.Server.Address = "https://iam.example.com"
+	config.Server.Timeout = 5 * time.Second
+	config.AuthInfo.SecretID = "old"
+	config.AuthInfo.SecretKey = "key"
+
+	clientConfig, err := NewClientConfigWithOverrides(*config, &ConfigOverrides{
+		AuthInfo: AuthInfo{SecretID: "id"},
+	}).ClientConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if clientConfig.Timeout != 5*time.Second {
+		t.Errorf("expected the timeout of the config, got %v", clientConfig.Timeout)
+	}
+
+	if clientConfig.ReloadTLSFiles {
+		t.Error("expected the TLS files not to be reloaded unless enabled")
+	}
+
+	if clientConfig.SecretID != "id" || clientConfig.SecretKey != "key" {
+		t.Errorf("unexpected secret %q/%q", clientConfig.SecretID, clientConfig.SecretKey)
+	}
+
+	_, err = NewClientConfigWithOverrides(*config, &ConfigOverrides{Timeout: "soon"}).ClientConfig()
+	if !IsConfigurationInvalid(err) {
+		t.Errorf("expected an invalid configuration, got %v", err)
+	}
+}
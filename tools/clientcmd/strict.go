@@ -0,0 +1,298 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/coding-hui/common/scheme"
+
+	restclient "github.com/coding-hui/wecoding-sdk-go/rest"
+)
+
+// errUnknownField is the error of the keys which are not iamconfig fields.
+var errUnknownField = errors.New("unknown field")
+
+// FieldError is an error of the iamconfig field at Path, e.g. users.dev.token.
+type FieldError struct {
+	// Path is the path of the field, its keys joined by dots.
+	Path string
+	// Line is the line of the field in the iamconfig, zero if unknown.
+	Line int
+	// Err is the problem of the field.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+
+	return fmt.Sprintf("line %d: %s: %v", e.Line, e.Path, e.Err)
+}
+
+// Unwrap returns the problem of the field.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// LoadStrict is like Load but rejects the keys which are not iamconfig fields,
+// e.g. typos, and validates the values of the iamconfig: addresses, durations,
+// mutually exclusive fields and referenced files. All the problems are returned
+// at once, as an invalid configuration error made of the yaml decoding errors and
+// FieldErrors.
+//
+// The references between the entries are not checked, as they may be defined by
+// another file of the loading rules.
+func LoadStrict(data []byte) (*Config, error) {
+	config := NewConfig()
+
+	document, _, err := parseDocument(data)
+	if err != nil || document == nil {
+		return config, err
+	}
+
+	var validationErrors []error
+
+	if err := document.Decode(config); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+
+		for _, message := range typeErr.Errors {
+			validationErrors = append(validationErrors, errors.New(message))
+		}
+	}
+
+	lines := make(map[string]int)
+	validationErrors = append(validationErrors, checkFields(document, reflect.TypeOf(config), "", lines)...)
+	validationErrors = append(validationErrors, validateStrict(config, lines)...)
+
+	if len(validationErrors) != 0 {
+		return nil, newErrConfigurationInvalid(validationErrors)
+	}
+
+	return config, nil
+}
+
+// checkFields returns an error for every key of node which is not a field of t.
+// The line of every path of node is recorded in lines.
+func checkFields(node *yaml.Node, t reflect.Type, path string, lines map[string]int) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if _, exists := lines[path]; !exists {
+		lines[path] = node.Line
+	}
+
+	var fieldErrors []error
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			fieldErrors = append(fieldErrors, checkFields(content, t, path, lines)...)
+		}
+	case yaml.AliasNode:
+		fieldErrors = append(fieldErrors, checkFields(node.Alias, t, path, lines)...)
+	case yaml.MappingNode:
+		var fields map[string]reflect.Type
+		if t.Kind() == reflect.Struct {
+			fields = yamlFields(t)
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			// the fields of a merged mapping belong to node
+			if key.Value == "<<" {
+				fieldErrors = append(fieldErrors, checkFields(value, t, path, lines)...)
+				continue
+			}
+
+			keyPath := joinPath(path, key.Value)
+			if _, exists := lines[keyPath]; !exists {
+				lines[keyPath] = key.Line
+			}
+
+			switch t.Kind() {
+			case reflect.Struct:
+				fieldType, known := fields[key.Value]
+				if !known {
+					fieldErrors = append(fieldErrors, &FieldError{Path: keyPath, Line: key.Line, Err: errUnknownField})
+					continue
+				}
+
+				fieldErrors = append(fieldErrors, checkFields(value, fieldType, keyPath, lines)...)
+			case reflect.Map:
+				fieldErrors = append(fieldErrors, checkFields(value, t.Elem(), keyPath, lines)...)
+			}
+		}
+	case yaml.SequenceNode:
+		if t.Kind() == reflect.Slice {
+			for i, item := range node.Content {
+				fieldErrors = append(fieldErrors, checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), lines)...)
+			}
+		}
+	}
+
+	return fieldErrors
+}
+
+// yamlFields returns the types of the fields of the struct t by their yaml key.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if strings.Contains(options, "inline") {
+			// inline maps hold the unknown fields
+			if field.Type.Kind() == reflect.Struct {
+				for name, fieldType := range yamlFields(field.Type) {
+					fields[name] = fieldType
+				}
+			}
+
+			continue
+		}
+
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+// joinPath appends key to the field path.
+func joinPath(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// validateStrict validates the values of every entry of config.
+func validateStrict(config *Config, lines map[string]int) []error {
+	var validationErrors []error
+
+	fieldErrors := func(path string, errs []error) {
+		for _, err := range errs {
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				fieldErr = &FieldError{Path: path, Line: lines[path], Err: err}
+			}
+
+			validationErrors = append(validationErrors, fieldErr)
+		}
+	}
+
+	if !isEmptyServer(config.Server) {
+		fieldErrors("server", validateServerInfo(*config.Server))
+		fieldErrors("server", validateServerFields("server", *config.Server, lines))
+	}
+
+	if !isEmptyAuthInfo(config.AuthInfo) {
+		fieldErrors("user", validateAuthInfo(*config.AuthInfo))
+	}
+
+	for name, server := range config.Servers {
+		path := joinPath("servers", name)
+		if server == nil {
+			server = &Server{}
+		}
+
+		fieldErrors(path, validateServerInfo(*server))
+		fieldErrors(path, validateServerFields(path, *server, lines))
+	}
+
+	for name, authInfo := range config.AuthInfos {
+		if authInfo != nil {
+			fieldErrors(joinPath("users", name), validateAuthInfo(*authInfo))
+		}
+	}
+
+	for name, context := range config.Contexts {
+		if context == nil || len(context.Server) == 0 {
+			fieldErrors(joinPath("contexts", name), []error{fmt.Errorf("server was not specified for context %q", name)})
+		}
+	}
+
+	return validationErrors
+}
+
+// validateServerFields validates the values of the fields of server, the errors
+// are FieldErrors of the fields.
+func validateServerFields(path string, server Server, lines map[string]int) []error {
+	var validationErrors []error
+
+	fieldError := func(key string, err error) {
+		keyPath := joinPath(path, key)
+		validationErrors = append(validationErrors, &FieldError{Path: keyPath, Line: lines[keyPath], Err: err})
+	}
+
+	if len(server.Address) != 0 {
+		if err := validateAddress(server.Address); err != nil {
+			fieldError("address", err)
+		}
+	}
+
+	if len(server.ProxyURL) != 0 {
+		if _, err := restclient.ProxyURL(server.ProxyURL); err != nil {
+			fieldError("proxy-url", err)
+		}
+	}
+
+	if server.Timeout < 0 {
+		fieldError("timeout", fmt.Errorf("timeout %v must not be negative", server.Timeout))
+	}
+
+	if server.RetryInterval < 0 {
+		fieldError("retry-interval", fmt.Errorf("retry interval %v must not be negative", server.RetryInterval))
+	}
+
+	if server.MaxRetries < 0 {
+		fieldError("max-retries", fmt.Errorf("max retries %d must not be negative", server.MaxRetries))
+	}
+
+	return validationErrors
+}
+
+// validateAddress checks the address is a URL or a host:port pair the client can use.
+func validateAddress(address string) error {
+	u, _, err := restclient.DefaultServerURL(address, "", scheme.GroupVersion{}, false)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("address %q must use http or https", address)
+	}
+
+	if len(u.Hostname()) == 0 {
+		return fmt.Errorf("address %q has no host", address)
+	}
+
+	return nil
+}
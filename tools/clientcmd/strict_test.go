@@ -0,0 +1,142 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package clientcmd
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadStrict(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.crt")
+
+	_, err := LoadStrict([]byte(`
+current-context: dev
+servers:
+  dev:
+    address: ftp://iam.example.com
+    timeout: -5s
+    certificate-authority: ` + missing + `
+users:
+  dev:
+    secretid: abc
+    token: abc
+    username: admin
+    client-certificate-data: cert
+contexts:
+  dev:
+    user: dev
+`))
+	if !IsConfigurationInvalid(err) {
+		t.Fatalf("expected an invalid configuration, got %v", err)
+	}
+
+	for _, want := range []string{
+		"line 6: servers.dev.timeout: timeout -5s must not be negative",
+		"line 5: servers.dev.address: address \"ftp://iam.example.com\" must use http or https",
+		"line 4: servers.dev: unable to read certificate-authority",
+		"line 10: users.dev.secretid: unknown field",
+		"line 9: users.dev: more than one authentication method found",
+		"line 9: users.dev: client-key-data or client-key must be specified",
+		"line 15: contexts.dev: server was not specified",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+
+	for _, err := range err.(errConfigurationInvalid) {
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Errorf("expected a field error, got %v", err)
+		}
+	}
+
+	if _, err := LoadStrict([]byte("server:\n  timeout: soon\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an invalid duration error, got %v", err)
+	}
+
+	_, err = LoadStrict([]byte("server:\n  address: localhost\n  proxy-url: ftp://proxy.example.com\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3: server.proxy-url: unsupported scheme") {
+		t.Errorf("expected an invalid proxy URL error, got %v", err)
+	}
+}
+
+func TestLoadStrictValid(t *testing.T) {
+	config, err := LoadStrict([]byte(`
+defaults: &defaults
+  timeout: 30s
+  max-retries: 3
+servers:
+  dev:
+    <<: *defaults
+    address: localhost:8080
+users:
+  dev:
+    exec:
+      command: iam-login
+      apiVersion: client.authentication.wecoding.top/v1
+      env:
+      - name: FOO
+        value: bar
+`))
+	if !IsConfigurationInvalid(err) || !strings.Contains(err.Error(), "line 2: defaults: unknown field") {
+		t.Fatalf("expected only the anchor holder to be unknown, got %v", err)
+	}
+
+	config, err = LoadStrict([]byte(`
+servers:
+  dev:
+    address: localhost:8080
+    timeout: 30s
+users:
+  dev:
+    exec:
+      command: iam-login
+      apiVersion: client.authentication.wecoding.top/v1
+      env:
+      - name: FOO
+        value: bar
+    as: alice
+    as-groups: [admins]
+    as-user-extra:
+      scopes: [view]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.AuthInfos["dev"].Exec.Env[0].Value != "bar" {
+		t.Errorf("unexpected config %+v", config.AuthInfos["dev"])
+	}
+
+	if config.AuthInfos["dev"].ImpersonateUserExtra["scopes"][0] != "view" {
+		t.Errorf("unexpected impersonation %+v", config.AuthInfos["dev"])
+	}
+
+	_, err = LoadStrict([]byte("users:\n  dev:\n    token: abc\n    as-groups: [admins]\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2: users.dev: requesting as-groups") {
+		t.Errorf("expected an impersonation error, got %v", err)
+	}
+}
+
+func TestLoadingRulesStrict(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config", `
+server:
+  adress: https://iam.example.com
+`)
+
+	rules := &ClientConfigLoadingRules{ExplicitPath: path, DisableEnvironment: true}
+	if _, err := rules.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rules.Strict = true
+	if _, err := rules.Load(); err == nil || !strings.Contains(err.Error(), "line 3: server.adress: unknown field") {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+}
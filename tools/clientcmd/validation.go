@@ -8,9 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"reflect"
 
 	utilerrors "github.com/coding-hui/common/errors"
+
+	"github.com/coding-hui/wecoding-sdk-go/plugin/pkg/client/auth/exec"
 )
 
 var (
@@ -26,6 +27,32 @@ var (
 	ErrEmptyServer = errors.New("server has no server defined")
 )
 
+// errContextNotFound is returned when the chosen context does not exist.
+type errContextNotFound struct {
+	ContextName string
+}
+
+func (e *errContextNotFound) Error() string {
+	return fmt.Sprintf("context was not found for specified context: %v", e.ContextName)
+}
+
+// IsContextNotFound returns a boolean indicating whether the error is known to
+// report that a context was not found.
+func IsContextNotFound(err error) bool {
+	var notFound *errContextNotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+
+	if aggregate, ok := err.(errConfigurationInvalid); ok {
+		return aggregate.visit(func(err error) bool {
+			return errors.As(err, &notFound)
+		})
+	}
+
+	return false
+}
+
 // NewEmptyConfigError returns an error wrapping the given message which IsEmptyConfig()
 // will recognize as an empty config error.
 func NewEmptyConfigError(message string) error {
@@ -125,8 +152,7 @@ func IsConfigurationInvalid(err error) bool {
 func validateServerInfo(serverInfo Server) []error {
 	validationErrors := make([]error, 0)
 
-	emptyServer := &Server{}
-	if reflect.DeepEqual(*emptyServer, serverInfo) {
+	if isEmptyServer(&serverInfo) {
 		return []error{ErrEmptyServer}
 	}
 
@@ -158,13 +184,36 @@ func validateServerInfo(serverInfo Server) []error {
 	return validationErrors
 }
 
+// validateContext looks for errors in the context. It is not transitive, so errors
+// in the referenced server and user are not included.
+func validateContext(contextName string, context Context, config Config) []error {
+	validationErrors := make([]error, 0)
+
+	if len(context.Server) == 0 {
+		validationErrors = append(validationErrors,
+			fmt.Errorf("server was not specified for context %q", contextName))
+	} else if _, exists := config.Servers[context.Server]; !exists {
+		validationErrors = append(validationErrors,
+			fmt.Errorf("server %q was not found for context %q", context.Server, contextName))
+	}
+
+	if len(context.AuthInfo) != 0 {
+		if _, exists := config.AuthInfos[context.AuthInfo]; !exists {
+			validationErrors = append(validationErrors,
+				fmt.Errorf("user %q was not found for context %q", context.AuthInfo, contextName))
+		}
+	}
+
+	return validationErrors
+}
+
 // validateAuthInfo looks for conflicts and errors in the auth info.
 func validateAuthInfo(authInfo AuthInfo) []error {
 	validationErrors := make([]error, 0)
 
 	usingAuthPath := false
 
-	methods := make([]string, 0, 3)
+	methods := make([]string, 0, 4)
 	if len(authInfo.Token) != 0 {
 		methods = append(methods, "token")
 	}
@@ -177,6 +226,37 @@ func validateAuthInfo(authInfo AuthInfo) []error {
 		methods = append(methods, "secretAuth")
 	}
 
+	if authInfo.Exec != nil {
+		methods = append(methods, "exec")
+
+		if len(authInfo.Exec.Command) == 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("command must be specified for exec to work"))
+		}
+
+		if len(authInfo.Exec.APIVersion) == 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("apiVersion must be specified for exec to work"))
+		}
+
+		switch authInfo.Exec.InteractiveMode {
+		case "", exec.NeverInteractiveMode, exec.IfAvailableInteractiveMode, exec.AlwaysInteractiveMode:
+		default:
+			validationErrors = append(validationErrors, fmt.Errorf("invalid interactiveMode %q for exec, expected %q, %q or %q",
+				authInfo.Exec.InteractiveMode, exec.NeverInteractiveMode, exec.IfAvailableInteractiveMode, exec.AlwaysInteractiveMode))
+		}
+
+		for _, v := range authInfo.Exec.Env {
+			if len(v.Name) == 0 {
+				validationErrors = append(validationErrors, fmt.Errorf("env variable name must be specified for exec to work"))
+			}
+		}
+	}
+
+	// the server can't impersonate groups or extra without a user
+	if len(authInfo.Impersonate) == 0 && (len(authInfo.ImpersonateGroups) != 0 || len(authInfo.ImpersonateUserExtra) != 0) {
+		validationErrors = append(validationErrors,
+			fmt.Errorf("requesting as-groups or as-user-extra without impersonating a user with as"))
+	}
+
 	// authPath also provides information for the client to identify the server,
 	// so allow multiple auth methods in that case
 	if (len(methods) > 1) && (!usingAuthPath) {
@@ -184,7 +264,7 @@ func validateAuthInfo(authInfo AuthInfo) []error {
 			fmt.Errorf("more than one authentication method found; found %v, only one is allowed", methods))
 	}
 
-	if len(authInfo.ClientCertificate) == 0 || len(authInfo.ClientCertificateData) == 0 {
+	if len(authInfo.ClientCertificate) == 0 && len(authInfo.ClientCertificateData) == 0 {
 		return validationErrors
 	}
 
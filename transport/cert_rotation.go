@@ -0,0 +1,220 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCertReloadInterval is how often the certificate files are checked for
+// changes.
+const DefaultCertReloadInterval = time.Minute
+
+// CertificateFiles are the files of the TLS credentials of a client.
+type CertificateFiles struct {
+	// CertFile is the path of the PEM-encoded client certificate.
+	CertFile string
+	// KeyFile is the path of the PEM-encoded client key.
+	KeyFile string
+	// CAFile is the path of the PEM-encoded trusted root certificates.
+	CAFile string
+}
+
+// CertificateReloader holds the client certificate and the trusted root certificates
+// read from files, and reads them again when they change, so the rotated certificates
+// are used without restarting the process. The files are checked at most once per
+// interval, when a connection is established or Check is called.
+type CertificateReloader struct {
+	files    CertificateFiles
+	interval time.Duration
+	now      func() time.Time
+
+	lock      sync.RWMutex
+	certData  []byte
+	keyData   []byte
+	caData    []byte
+	cert      *tls.Certificate
+	roots     *x509.CertPool
+	checked   time.Time
+	listeners []func()
+}
+
+// NewCertificateReloader returns a CertificateReloader of files, checking them for
+// changes every interval. The files are read immediately, an error is returned if
+// they are invalid.
+func NewCertificateReloader(files CertificateFiles, interval time.Duration) (*CertificateReloader, error) {
+	if (len(files.CertFile) == 0) != (len(files.KeyFile) == 0) {
+		return nil, errors.New("both a client certificate and a client key file must be specified")
+	}
+
+	r := &CertificateReloader{
+		files:    files,
+		interval: interval,
+		now:      time.Now,
+	}
+
+	if _, err := r.reload(r.now()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// OnRotate registers f to be called when the certificates changed, e.g. to close
+// the idle connections established with the previous ones.
+func (r *CertificateReloader) OnRotate(f func()) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.listeners = append(r.listeners, f)
+}
+
+// Check reads the files again if they were last read at least the interval ago,
+// and returns whether the certificates changed. If the files can't be read or are
+// invalid, e.g. while they are being replaced, the previous certificates are kept
+// and the error is returned.
+func (r *CertificateReloader) Check() (bool, error) {
+	now := r.now()
+
+	r.lock.RLock()
+	due := now.Sub(r.checked) >= r.interval
+	r.lock.RUnlock()
+
+	if !due {
+		return false, nil
+	}
+
+	return r.reload(now)
+}
+
+// reload reads the files unless another call did it since it was due, swaps in
+// the certificates if they changed and notifies the listeners.
+func (r *CertificateReloader) reload(now time.Time) (bool, error) {
+	r.lock.Lock()
+
+	if !r.checked.IsZero() && now.Sub(r.checked) < r.interval {
+		r.lock.Unlock()
+		return false, nil
+	}
+
+	r.checked = now
+
+	changed, err := r.load()
+	listeners := r.listeners
+
+	r.lock.Unlock()
+
+	if changed {
+		for _, listener := range listeners {
+			listener()
+		}
+	}
+
+	return changed, err
+}
+
+// load reads the files and parses them if they changed. It must be called with
+// the lock held.
+func (r *CertificateReloader) load() (bool, error) {
+	certData, err := readFile(r.files.CertFile)
+	if err != nil {
+		return false, err
+	}
+
+	keyData, err := readFile(r.files.KeyFile)
+	if err != nil {
+		return false, err
+	}
+
+	caData, err := readFile(r.files.CAFile)
+	if err != nil {
+		return false, err
+	}
+
+	if bytes.Equal(certData, r.certData) && bytes.Equal(keyData, r.keyData) && bytes.Equal(caData, r.caData) {
+		return false, nil
+	}
+
+	var cert *tls.Certificate
+	if len(r.files.CertFile) != 0 {
+		pair, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return false, fmt.Errorf("unable to load client certificate %s: %w", r.files.CertFile, err)
+		}
+
+		cert = &pair
+	}
+
+	var roots *x509.CertPool
+	if len(r.files.CAFile) != 0 {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caData) {
+			return false, fmt.Errorf("no certificate found in %s", r.files.CAFile)
+		}
+	}
+
+	// the first load is not a rotation
+	rotated := r.cert != nil || r.roots != nil
+
+	r.certData, r.keyData, r.caData = certData, keyData, caData
+	r.cert, r.roots = cert, roots
+
+	return rotated, nil
+}
+
+// readFile returns the contents of the file at path, nil if path is empty.
+func readFile(path string) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	return os.ReadFile(path)
+}
+
+// GetClientCertificate returns the current client certificate, it can be used as
+// tls.Config.GetClientCertificate.
+func (r *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	_, _ = r.Check()
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.cert == nil {
+		// no client certificate is sent to the server
+		return &tls.Certificate{}, nil
+	}
+
+	return r.cert, nil
+}
+
+// RootCAs returns the current trusted root certificates, nil if CAFile is not set.
+func (r *CertificateReloader) RootCAs() *x509.CertPool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.roots
+}
+
+// Configure makes config use the certificates of the reloader: the client
+// certificate if CertFile is set, which is read again for every handshake, and
+// the current trusted root certificates if CAFile is set. The server certificates
+// are verified by crypto/tls as usual, so a new config must be configured when
+// the trusted root certificates rotate, see OnRotate.
+func (r *CertificateReloader) Configure(config *tls.Config) {
+	if len(r.files.CertFile) != 0 {
+		config.GetClientCertificate = r.GetClientCertificate
+	}
+
+	if len(r.files.CAFile) != 0 {
+		config.RootCAs = r.RootCAs()
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for localhost named name and
+// its key to dir, and returns their paths.
+func writeCertificate(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "first")
+
+	reloader, err := NewCertificateReloader(CertificateFiles{CertFile: certFile, KeyFile: keyFile, CAFile: certFile}, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	reloader.now = func() time.Time { return now }
+
+	var rotations int
+	reloader.OnRotate(func() { rotations++ })
+
+	writeCertificate(t, dir, "second")
+
+	cert, _ := reloader.GetClientCertificate(nil)
+	if name := commonName(t, cert); name != "first" {
+		t.Errorf("expected the certificate to be cached until the interval elapsed, got %q", name)
+	}
+
+	now = now.Add(time.Hour)
+
+	cert, _ = reloader.GetClientCertificate(nil)
+	if name := commonName(t, cert); name != "second" || rotations != 1 {
+		t.Errorf("expected the rotated certificate, got %q after %d rotations", name, rotations)
+	}
+
+	// a partially written key is not used
+	writeFile(t, keyFile, []byte("garbage"))
+
+	now = now.Add(time.Hour)
+
+	if changed, err := reloader.Check(); changed || err == nil {
+		t.Errorf("expected an error, got %v, %v", changed, err)
+	}
+
+	cert, _ = reloader.GetClientCertificate(nil)
+	if name := commonName(t, cert); name != "second" || rotations != 1 {
+		t.Errorf("expected the previous certificate to be kept, got %q after %d rotations", name, rotations)
+	}
+}
+
+func TestCertificateReloaderRootCAs(t *testing.T) {
+	dir := t.TempDir()
+	certFile, _ := writeCertificate(t, dir, "server")
+
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+
+	serverCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reloader, err := NewCertificateReloader(CertificateFiles{CAFile: certFile}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := &tls.Config{}
+	reloader.Configure(config)
+
+	if config.InsecureSkipVerify || config.RootCAs == nil {
+		t.Fatalf("expected the server certificates to be verified with the CA file, got %+v", config)
+	}
+
+	if _, err := serverCert.Verify(x509.VerifyOptions{Roots: config.RootCAs, DNSName: "localhost"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := serverCert.Verify(x509.VerifyOptions{Roots: config.RootCAs, DNSName: "other.example.com"}); err == nil {
+		t.Error("expected the server certificate to be rejected for another name")
+	}
+
+	// the server certificate is no longer trusted once the CA file is rotated
+	writeCertificate(t, dir, "other")
+
+	if _, err := reloader.Check(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := serverCert.Verify(x509.VerifyOptions{Roots: reloader.RootCAs(), DNSName: "localhost"}); err == nil {
+		t.Error("expected the server certificate to be rejected")
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DebugLevel is the verbosity of the debug logs of the requests.
+type DebugLevel int
+
+// Defines the debug levels, each one logs what the previous ones do.
+const (
+	// DebugNone logs nothing.
+	DebugNone DebugLevel = iota
+	// DebugURLTiming logs the method, URL, status and latency of the requests.
+	DebugURLTiming
+	// DebugHeaders logs the headers of the requests and responses as well.
+	DebugHeaders
+	// DebugBodies logs the JSON bodies of the requests and responses as well,
+	// the other bodies are logged by size.
+	DebugBodies
+)
+
+// redacted replaces the credentials in the debug logs, as in the rest.Config strings.
+const redacted = "--- REDACTED ---"
+
+// redactedHeaders are the headers whose values are redacted.
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// redactedFields are the JSON fields whose values are redacted, compared in lower
+// case without underscores and dashes.
+var redactedFields = map[string]bool{
+	"password":     true,
+	"secretkey":    true,
+	"token":        true,
+	"accesstoken":  true,
+	"refreshtoken": true,
+}
+
+// DebugConfig configures the debug logs of the requests.
+type DebugConfig struct {
+	// Logger receives the logs at the debug level. If nil, they are written
+	// to the standard error as text.
+	Logger *slog.Logger
+	// Level is the verbosity of the logs. If DebugNone, nothing is logged
+	// unless CurlCommand is set.
+	Level DebugLevel
+	// CurlCommand logs a curl command equivalent to every request, with the
+	// credentials redacted.
+	CurlCommand bool
+}
+
+// Enabled returns whether c logs anything.
+func (c DebugConfig) Enabled() bool {
+	return c.Level > DebugNone || c.CurlCommand
+}
+
+type debuggingRoundTripper struct {
+	config DebugConfig
+	rt     http.RoundTripper
+}
+
+var _ WrappedRoundTripper = &debuggingRoundTripper{}
+
+// NewDebuggingRoundTripper returns a round tripper logging the requests and their
+// responses as configured by config, with the credentials redacted.
+func NewDebuggingRoundTripper(config DebugConfig, rt http.RoundTripper) http.RoundTripper {
+	if config.Logger == nil {
+		// the default logger drops the debug logs
+		config.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	return &debuggingRoundTripper{config: config, rt: rt}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *debuggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	logger := rt.config.Logger
+	level := rt.config.Level
+
+	var reqBody []byte
+	if (level >= DebugBodies || rt.config.CurlCommand) && req.GetBody != nil {
+		reqBody = readRequestBody(req)
+	}
+
+	if rt.config.CurlCommand {
+		logger.LogAttrs(ctx, slog.LevelDebug, "HTTP request", slog.String("curl", curlCommand(req, reqBody)))
+	}
+
+	start := time.Now()
+	resp, err := rt.rt.RoundTrip(req)
+	latency := time.Since(start)
+
+	if level < DebugURLTiming {
+		return resp, err
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.Redacted()),
+		slog.Duration("latency", latency),
+	}
+
+	if level >= DebugHeaders {
+		attrs = append(attrs, headerAttr("requestHeaders", req.Header))
+	}
+
+	if level >= DebugBodies && req.GetBody != nil {
+		attrs = append(attrs, bodyAttr("requestBody", req.Header, reqBody))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		logger.LogAttrs(ctx, slog.LevelDebug, "HTTP response", attrs...)
+
+		return resp, err
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+
+	if level >= DebugHeaders {
+		attrs = append(attrs, headerAttr("responseHeaders", resp.Header))
+	}
+
+	if level >= DebugBodies && !isStreaming(req, resp) {
+		var body []byte
+
+		body, resp.Body, err = readResponseBody(resp.Body)
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+			logger.LogAttrs(ctx, slog.LevelDebug, "HTTP response", attrs...)
+
+			return nil, err
+		}
+
+		attrs = append(attrs, bodyAttr("responseBody", resp.Header, body))
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "HTTP response", attrs...)
+
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped round tripper.
+func (rt *debuggingRoundTripper) CloseIdleConnections() {
+	CloseIdleConnections(rt.rt)
+}
+
+// WrappedRoundTripper implements WrappedRoundTripper.
+func (rt *debuggingRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.rt
+}
+
+// readRequestBody returns a copy of the body of req, leaving the one sent intact.
+func readRequestBody(req *http.Request) []byte {
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	data, _ := io.ReadAll(body)
+
+	return data
+}
+
+// readResponseBody reads body and returns its data with a reader replacing it.
+func readResponseBody(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	if body == nil || body == http.NoBody {
+		return nil, body, nil
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+
+	return data, io.NopCloser(bytes.NewReader(data)), err
+}
+
+// streamingKey is the context key marking the requests whose response is streamed.
+type streamingKey struct{}
+
+// WithStreamingResponse returns a context marking the requests made with it as
+// streaming their response, e.g. watches, so the debug logs don't read it.
+func WithStreamingResponse(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+// isStreaming returns whether the response is streamed, e.g. an event stream or a
+// watch, which is not read so it can still be consumed as it arrives.
+func isStreaming(req *http.Request, resp *http.Response) bool {
+	if streaming, _ := req.Context().Value(streamingKey{}).(bool); streaming {
+		return true
+	}
+
+	if req.URL.Query().Get("watch") == "true" {
+		return true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	return mediaType == "text/event-stream"
+}
+
+// headerAttr returns the header as an attribute with the credentials redacted.
+func headerAttr(key string, header http.Header) slog.Attr {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	attrs := make([]any, 0, len(names))
+	for _, name := range names {
+		attrs = append(attrs, slog.String(name, strings.Join(redactHeader(name, header[name]), ", ")))
+	}
+
+	return slog.Group(key, attrs...)
+}
+
+// redactHeader returns the values of the header name with the credentials redacted.
+func redactHeader(name string, values []string) []string {
+	name = http.CanonicalHeaderKey(name)
+	if !redactedHeaders[name] {
+		return values
+	}
+
+	redactedValues := make([]string, len(values))
+	for i, value := range values {
+		// keep the authentication scheme, e.g. Bearer
+		if scheme, _, found := strings.Cut(value, " "); found && name != "Cookie" && name != "Set-Cookie" {
+			redactedValues[i] = scheme + " " + redacted
+		} else {
+			redactedValues[i] = redacted
+		}
+	}
+
+	return redactedValues
+}
+
+// bodyAttr returns the body as an attribute, with the credentials of JSON bodies
+// redacted; the other bodies may hold credentials in any form and are logged by size.
+func bodyAttr(key string, header http.Header, body []byte) slog.Attr {
+	if len(body) == 0 {
+		return slog.String(key, "")
+	}
+
+	if redactedBody, ok := redactBody(body); ok {
+		return slog.String(key, redactedBody)
+	}
+
+	return slog.String(key, fmt.Sprintf("[%d bytes of %s]", len(body), header.Get("Content-Type")))
+}
+
+// redactBody returns the JSON body with the credentials redacted, or false if the
+// body is not JSON.
+func redactBody(body []byte) (string, bool) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", false
+	}
+
+	data, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+// redactValue redacts the credential fields of a decoded JSON value.
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			name := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+			if _, isString := field.(string); isString && redactedFields[name] {
+				value[key] = redacted
+			} else {
+				value[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+
+	return value
+}
+
+// curlCommand returns a curl command sending req, with the credentials redacted.
+func curlCommand(req *http.Request, body []byte) string {
+	var command strings.Builder
+
+	fmt.Fprintf(&command, "curl -v -X%s", req.Method)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range redactHeader(name, req.Header[name]) {
+			fmt.Fprintf(&command, " -H %s", shellQuote(name+": "+value))
+		}
+	}
+
+	fmt.Fprintf(&command, " %s", shellQuote(req.URL.Redacted()))
+
+	if len(body) != 0 {
+		if redactedBody, ok := redactBody(body); ok {
+			fmt.Fprintf(&command, " -d %s", shellQuote(redactedBody))
+		} else {
+			fmt.Fprintf(&command, " --data-binary @- # %d bytes of %s", len(body), req.Header.Get("Content-Type"))
+		}
+	}
+
+	return command.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebuggingRoundTripper(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	rt := NewDebuggingRoundTripper(DebugConfig{Logger: logger, Level: DebugBodies, CurlCommand: true},
+		RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			if string(body) != `{"username":"admin","password":"secret"}` {
+				t.Errorf("expected the request body to be sent intact, got %s", body)
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"data":{"accessToken":"abc","expire":3600}}`)),
+			}, nil
+		}))
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/login",
+		strings.NewReader(`{"username":"admin","password":"secret"}`))
+	req.Header.Set("Authorization", "Basic YWRtaW46c2VjcmV0")
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"data":{"accessToken":"abc","expire":3600}}` {
+		t.Errorf("expected the response body to be read intact, got %s", body)
+	}
+
+	out := logs.String()
+	for _, want := range []string{
+		`curl="curl -v -XPOST -H 'Authorization: Basic --- REDACTED ---' 'http://localhost/login'`,
+		`method=POST url=http://localhost/login`,
+		`requestHeaders.Authorization="Basic --- REDACTED ---"`,
+		`status=200`,
+		`responseHeaders.Content-Type=application/json`,
+		`\"accessToken\":\"--- REDACTED ---\"`,
+		`\"password\":\"--- REDACTED ---\"`,
+		`\"expire\":3600`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the logs:\n%s", want, out)
+		}
+	}
+
+	for _, secret := range []string{"YWRtaW46c2VjcmV0", "secret", `"abc"`} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %s to be redacted from the logs:\n%s", secret, out)
+		}
+	}
+}
+
+func TestDebuggingRoundTripperLevels(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	rt := NewDebuggingRoundTripper(DebugConfig{Logger: logger, Level: DebugURLTiming},
+		RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+		}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/users", nil)
+	req.Header.Set("X-Request-Id", "123")
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "method=GET url=http://localhost/users latency=") || !strings.Contains(out, "status=404") {
+		t.Errorf("expected the URL and status in the logs:\n%s", out)
+	}
+
+	if strings.Contains(out, "X-Request-Id") || strings.Contains(out, "curl") {
+		t.Errorf("expected no headers nor curl command in the logs:\n%s", out)
+	}
+}
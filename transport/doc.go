@@ -0,0 +1,7 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package transport provides the building blocks used by the clients to
+// authenticate and send their requests.
+package transport // import "github.com/coding-hui/wecoding-sdk-go/transport"
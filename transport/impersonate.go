@@ -0,0 +1,99 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Defines the headers asking the server to evaluate a request as another user.
+const (
+	// ImpersonateUserHeader is used to impersonate a particular user during an API server request.
+	ImpersonateUserHeader = "Impersonate-User"
+	// ImpersonateGroupHeader is used to impersonate a particular group during an API server request.
+	// It can be repeated multiple times for multiple groups.
+	ImpersonateGroupHeader = "Impersonate-Group"
+	// ImpersonateUserExtraHeaderPrefix is a prefix for a header used to impersonate an entry in the
+	// extra map[string][]string for user.Info. The key for the `extra` map is suffix.
+	// The same key can be repeated multiple times to have multiple elements in the slice under a single key.
+	ImpersonateUserExtraHeaderPrefix = "Impersonate-Extra-"
+)
+
+// ImpersonationConfig has all the available impersonation options.
+type ImpersonationConfig struct {
+	// UserName is the username to impersonate on each request.
+	UserName string
+	// Groups are the groups to impersonate on each request.
+	Groups []string
+	// Extra is a free-form field which can be used to link some authentication information
+	// to authorization information. This field allows you to impersonate it.
+	Extra map[string][]string
+}
+
+// Empty returns whether c impersonates no one.
+func (c ImpersonationConfig) Empty() bool {
+	return len(c.UserName) == 0 && len(c.Groups) == 0 && len(c.Extra) == 0
+}
+
+// SetImpersonationHeaders sets the impersonation headers of config on header,
+// unless it already impersonates a user, e.g. set for a single request.
+func SetImpersonationHeaders(header http.Header, config ImpersonationConfig) {
+	if config.Empty() || len(header.Get(ImpersonateUserHeader)) != 0 {
+		return
+	}
+
+	if len(config.UserName) != 0 {
+		header.Set(ImpersonateUserHeader, config.UserName)
+	}
+
+	for _, group := range config.Groups {
+		header.Add(ImpersonateGroupHeader, group)
+	}
+
+	for key, values := range config.Extra {
+		// the keys may hold characters which are not allowed in header names
+		name := ImpersonateUserExtraHeaderPrefix + url.PathEscape(key)
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+}
+
+type impersonatingRoundTripper struct {
+	impersonate ImpersonationConfig
+	rt          http.RoundTripper
+}
+
+var _ WrappedRoundTripper = &impersonatingRoundTripper{}
+
+// NewImpersonatingRoundTripper will add an Impersonate-User header to a request
+// unless it has one already, along with the groups and extra of impersonate.
+func NewImpersonatingRoundTripper(impersonate ImpersonationConfig, rt http.RoundTripper) http.RoundTripper {
+	return &impersonatingRoundTripper{impersonate: impersonate, rt: rt}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *impersonatingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.impersonate.Empty() || len(req.Header.Get(ImpersonateUserHeader)) != 0 {
+		return rt.rt.RoundTrip(req)
+	}
+
+	// a round tripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	SetImpersonationHeaders(req.Header, rt.impersonate)
+
+	return rt.rt.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped round tripper.
+func (rt *impersonatingRoundTripper) CloseIdleConnections() {
+	CloseIdleConnections(rt.rt)
+}
+
+// WrappedRoundTripper implements WrappedRoundTripper.
+func (rt *impersonatingRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.rt
+}
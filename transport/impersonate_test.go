@@ -0,0 +1,53 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestImpersonatingRoundTripper(t *testing.T) {
+	var got http.Header
+	rt := NewImpersonatingRoundTripper(ImpersonationConfig{
+		UserName: "alice",
+		Groups:   []string{"admins", "developers"},
+		Extra:    map[string][]string{"scopes": {"view", "edit"}, "example.com/tenant": {"acme"}},
+	}, RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := http.Header{
+		ImpersonateUserHeader:                                     {"alice"},
+		ImpersonateGroupHeader:                                    {"admins", "developers"},
+		ImpersonateUserExtraHeaderPrefix + "Scopes":               {"view", "edit"},
+		ImpersonateUserExtraHeaderPrefix + "Example.com%2ftenant": {"acme"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected headers %v, got %v", want, got)
+	}
+
+	if len(req.Header) != 0 {
+		t.Error("expected the original request to be left unmodified")
+	}
+
+	// a user impersonated by the request is kept
+	req.Header.Set(ImpersonateUserHeader, "bob")
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Get(ImpersonateUserHeader) != "bob" || len(got.Values(ImpersonateGroupHeader)) != 0 {
+		t.Errorf("expected the request to impersonate bob only, got %v", got)
+	}
+}
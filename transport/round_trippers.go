@@ -0,0 +1,145 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"net/http"
+	"time"
+)
+
+// RoundTripperFunc adapts a function to an http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WrapperFunc wraps an http.RoundTripper when a new transport is created for a
+// client, allowing per connection behavior to be injected.
+type WrapperFunc func(rt http.RoundTripper) http.RoundTripper
+
+// Wrappers accepts any number of wrappers and returns a wrapper function that is
+// the equivalent of calling each of them in order. Nil values are ignored, which
+// makes this function convenient for incrementally wrapping a function.
+func Wrappers(fns ...WrapperFunc) WrapperFunc {
+	if len(fns) == 0 {
+		return nil
+	}
+
+	// optimize the common case of wrapping a possibly nil transport wrapper
+	// with an additional wrapper
+	if len(fns) == 2 && fns[0] == nil {
+		return fns[1]
+	}
+
+	return func(rt http.RoundTripper) http.RoundTripper {
+		base := rt
+		for _, fn := range fns {
+			if fn != nil {
+				base = fn(base)
+			}
+		}
+
+		return base
+	}
+}
+
+// WrappedRoundTripper is implemented by the round trippers wrapping another one.
+type WrappedRoundTripper interface {
+	http.RoundTripper
+	// WrappedRoundTripper returns the wrapped round tripper.
+	WrappedRoundTripper() http.RoundTripper
+}
+
+// CloseIdleConnections closes the idle connections of rt, or of the round tripper
+// it wraps, if it is able to.
+func CloseIdleConnections(rt http.RoundTripper) {
+	for rt != nil {
+		if closer, ok := rt.(interface{ CloseIdleConnections() }); ok {
+			closer.CloseIdleConnections()
+			return
+		}
+
+		wrapped, ok := rt.(WrappedRoundTripper)
+		if !ok {
+			return
+		}
+
+		rt = wrapped.WrappedRoundTripper()
+	}
+}
+
+type userAgentRoundTripper struct {
+	agent string
+	rt    http.RoundTripper
+}
+
+var _ WrappedRoundTripper = &userAgentRoundTripper{}
+
+// NewUserAgentRoundTripper returns a round tripper setting the User-Agent header
+// of the requests which have none to agent.
+func NewUserAgentRoundTripper(agent string, rt http.RoundTripper) http.RoundTripper {
+	return &userAgentRoundTripper{agent: agent, rt: rt}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(req.Header.Get("User-Agent")) != 0 {
+		return rt.rt.RoundTrip(req)
+	}
+
+	// a round tripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", rt.agent)
+
+	return rt.rt.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped round tripper.
+func (rt *userAgentRoundTripper) CloseIdleConnections() {
+	CloseIdleConnections(rt.rt)
+}
+
+// WrappedRoundTripper implements WrappedRoundTripper.
+func (rt *userAgentRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.rt
+}
+
+// ObserverFunc is given the outcome of a request: its response or error, and
+// how long it took. It is used to record metrics.
+type ObserverFunc func(req *http.Request, resp *http.Response, err error, latency time.Duration)
+
+type metricsRoundTripper struct {
+	observe ObserverFunc
+	rt      http.RoundTripper
+}
+
+var _ WrappedRoundTripper = &metricsRoundTripper{}
+
+// NewMetricsRoundTripper returns a round tripper giving the outcome of every
+// request to observe. The latency does not include reading the response body.
+func NewMetricsRoundTripper(observe ObserverFunc, rt http.RoundTripper) http.RoundTripper {
+	return &metricsRoundTripper{observe: observe, rt: rt}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *metricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.rt.RoundTrip(req)
+	rt.observe(req, resp, err, time.Since(start))
+
+	return resp, err
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped round tripper.
+func (rt *metricsRoundTripper) CloseIdleConnections() {
+	CloseIdleConnections(rt.rt)
+}
+
+// WrappedRoundTripper implements WrappedRoundTripper.
+func (rt *metricsRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.rt
+}
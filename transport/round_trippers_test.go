@@ -0,0 +1,89 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUserAgentRoundTripper(t *testing.T) {
+	var got string
+	rt := NewUserAgentRoundTripper("test/1.0", RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header.Get("User-Agent")
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "test/1.0" {
+		t.Errorf("expected User-Agent %q, got %q", "test/1.0", got)
+	}
+
+	if len(req.Header.Get("User-Agent")) != 0 {
+		t.Error("expected the original request to be left unmodified")
+	}
+
+	req.Header.Set("User-Agent", "other")
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "other" {
+		t.Errorf("expected the User-Agent of the request to be kept, got %q", got)
+	}
+}
+
+func TestWrappers(t *testing.T) {
+	var calls []string
+	wrapper := func(name string) WrapperFunc {
+		return func(rt http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+
+				return rt.RoundTrip(req)
+			})
+		}
+	}
+
+	var observed time.Duration
+	base := NewMetricsRoundTripper(func(_ *http.Request, resp *http.Response, err error, latency time.Duration) {
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected outcome: %v, %v", resp, err)
+		}
+		observed = latency
+	}, RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		calls = append(calls, "base")
+		time.Sleep(time.Millisecond)
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	rt := Wrappers(wrapper("inner"), nil, wrapper("outer"))(base)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"outer", "inner", "base"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+
+	if observed < time.Millisecond {
+		t.Errorf("expected the latency to be observed, got %v", observed)
+	}
+
+	if Wrappers() != nil {
+		t.Error("expected no wrapper")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenRefreshPeriod is how long a token read from a file is cached
+// before the file is read again.
+const DefaultTokenRefreshPeriod = time.Minute
+
+// TokenSource returns bearer tokens.
+type TokenSource interface {
+	// Token returns a token or an error.
+	Token() (string, error)
+}
+
+// ResettableTokenSource is a TokenSource which caches its token and can be told
+// to discard it, e.g. after the server rejected it.
+type ResettableTokenSource interface {
+	TokenSource
+	// ResetTokenOlderThan discards the cached token if it was obtained before t,
+	// so the next call to Token fetches a new one.
+	ResetTokenOlderThan(t time.Time)
+}
+
+// ContextTokenSource is a TokenSource whose tokens can be obtained for a request,
+// within the deadline of its context.
+type ContextTokenSource interface {
+	TokenSource
+	// TokenContext returns a token, or an error if ctx is done before one is obtained.
+	TokenContext(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func() (string, error)
+
+// Token implements TokenSource.
+func (f TokenSourceFunc) Token() (string, error) {
+	return f()
+}
+
+// NewCachedFileTokenSource returns a ResettableTokenSource reading the token from
+// the file at path, e.g. a projected token which is rotated by the platform. The
+// file is read again once the token is older than DefaultTokenRefreshPeriod or
+// has been reset. If the file can't be read, the last token successfully read is
+// returned.
+func NewCachedFileTokenSource(path string) ResettableTokenSource {
+	return NewCachedTokenSource(&fileTokenSource{path: path}, DefaultTokenRefreshPeriod)
+}
+
+// NewCachedTokenSource returns a ResettableTokenSource caching the tokens of base
+// for period. If base fails, the last token it returned is used until it succeeds
+// again. It is safe for concurrent use, only one call to base is made at a time.
+func NewCachedTokenSource(base TokenSource, period time.Duration) ResettableTokenSource {
+	return &cachingTokenSource{
+		base:   base,
+		period: period,
+		now:    time.Now,
+	}
+}
+
+// cachingTokenSource implements ResettableTokenSource.
+type cachingTokenSource struct {
+	base   TokenSource
+	period time.Duration
+	now    func() time.Time
+
+	lock sync.RWMutex
+	tok  string
+	t    time.Time
+}
+
+var _ ResettableTokenSource = &cachingTokenSource{}
+
+// Token implements TokenSource.
+func (ts *cachingTokenSource) Token() (string, error) {
+	now := ts.now()
+
+	ts.lock.RLock()
+	tok, fresh := ts.tok, ts.fresh(now)
+	ts.lock.RUnlock()
+
+	if fresh {
+		return tok, nil
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	// another caller may have refreshed the token while waiting for the lock
+	if ts.fresh(now) {
+		return ts.tok, nil
+	}
+
+	tok, err := ts.base.Token()
+	if err != nil {
+		if len(ts.tok) == 0 {
+			return "", err
+		}
+
+		return ts.tok, nil
+	}
+
+	ts.tok, ts.t = tok, ts.now()
+
+	return tok, nil
+}
+
+// ResetTokenOlderThan implements ResettableTokenSource.
+func (ts *cachingTokenSource) ResetTokenOlderThan(t time.Time) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if ts.t.Before(t) {
+		ts.t = time.Time{}
+	}
+}
+
+// fresh returns whether the cached token can be used at now. It must be called
+// with the lock held.
+func (ts *cachingTokenSource) fresh(now time.Time) bool {
+	return len(ts.tok) != 0 && !ts.t.IsZero() && now.Sub(ts.t) < ts.period
+}
+
+// fileTokenSource reads the token from a file.
+type fileTokenSource struct {
+	path string
+}
+
+// Token implements TokenSource.
+func (ts *fileTokenSource) Token() (string, error) {
+	data, err := os.ReadFile(ts.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file %q: %w", ts.path, err)
+	}
+
+	tok := strings.TrimSpace(string(data))
+	if len(tok) == 0 {
+		return "", fmt.Errorf("read empty token from file %q", ts.path)
+	}
+
+	return tok, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachingTokenSource(t *testing.T) {
+	var (
+		calls atomic.Int32
+		err   error
+	)
+
+	now := time.Now()
+	ts := NewCachedTokenSource(TokenSourceFunc(func() (string, error) {
+		if err != nil {
+			return "", err
+		}
+
+		calls.Add(1)
+
+		return "token", nil
+	}), time.Minute).(*cachingTokenSource)
+	ts.now = func() time.Time { return now }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if tok, err := ts.Token(); err != nil || tok != "token" {
+				t.Errorf("unexpected token %q, error %v", tok, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a single call to the base token source, got %d", got)
+	}
+
+	now = now.Add(time.Minute)
+	ts.Token()
+
+	if calls.Load() != 2 {
+		t.Errorf("expected the token to be refreshed after its period, got %d calls", calls.Load())
+	}
+
+	ts.ResetTokenOlderThan(now.Add(-time.Second))
+	ts.Token()
+
+	if calls.Load() != 2 {
+		t.Errorf("expected a newer token to be kept, got %d calls", calls.Load())
+	}
+
+	ts.ResetTokenOlderThan(now.Add(time.Second))
+
+	err = errors.New("unavailable")
+	if tok, err := ts.Token(); err != nil || tok != "token" {
+		t.Errorf("expected the last token on failure, got %q, error %v", tok, err)
+	}
+}
+
+func TestCachedFileTokenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+
+	ts := NewCachedFileTokenSource(path)
+	if _, err := ts.Token(); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+
+	if err := os.WriteFile(path, []byte("  token\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tok, err := ts.Token(); err != nil || tok != "token" {
+		t.Errorf("unexpected token %q, error %v", tok, err)
+	}
+}
@@ -0,0 +1,7 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package flowcontrol provides the rate limiters used to pace the requests
+// sent by the clients.
+package flowcontrol
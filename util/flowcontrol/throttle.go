@@ -0,0 +1,209 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package flowcontrol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// minRateFraction bounds how much an adaptive limiter slows down: its rate
+	// never drops below its configured QPS divided by minRateFraction.
+	minRateFraction = 16
+	// recoveryFraction is the fraction of the configured QPS an adaptive limiter
+	// recovers after every successful request.
+	recoveryFraction = 0.1
+)
+
+// RateLimiter paces requests.
+type RateLimiter interface {
+	// TryAccept returns true if a token is taken immediately. Otherwise,
+	// it returns false.
+	TryAccept() bool
+	// Accept returns once a token becomes available.
+	Accept()
+	// Wait returns nil once a token becomes available, or an error if ctx is
+	// done or its deadline is too close to get one.
+	Wait(ctx context.Context) error
+	// QPS returns the current QPS of this rate limiter.
+	QPS() float32
+}
+
+// AdaptiveRateLimiter is a RateLimiter adapting its rate to the feedback of the server.
+type AdaptiveRateLimiter interface {
+	RateLimiter
+	// Throttled slows the limiter down after the server rejected a request for
+	// exceeding its rate limit. No token is given out before retryAfter, the
+	// delay suggested by the server, has elapsed.
+	Throttled(retryAfter time.Duration)
+	// Succeeded lets the limiter recover its configured rate after the server
+	// accepted a request.
+	Succeeded()
+}
+
+// tokenBucketRateLimiter implements AdaptiveRateLimiter with a token bucket.
+type tokenBucketRateLimiter struct {
+	lock sync.Mutex
+
+	// qps is the configured rate, rate the current one.
+	qps   float64
+	rate  float64
+	burst float64
+
+	// tokens is the number of tokens available at last, it is negative when
+	// tokens were reserved in advance.
+	tokens float64
+	last   time.Time
+}
+
+var _ AdaptiveRateLimiter = &tokenBucketRateLimiter{}
+
+// NewTokenBucketRateLimiter creates a rate limiter which allows bursts of up to
+// burst requests and then qps requests per second on average. The bucket is
+// initially full. The limiter slows down when it is Throttled, down to 1/16 of
+// qps, and recovers 10% of qps after every Succeeded request.
+func NewTokenBucketRateLimiter(qps float32, burst int) AdaptiveRateLimiter {
+	if qps <= 0 {
+		panic(fmt.Sprintf("qps must be positive, got %v", qps))
+	}
+
+	if burst <= 0 {
+		panic(fmt.Sprintf("burst must be positive, got %v", burst))
+	}
+
+	return &tokenBucketRateLimiter{
+		qps:    float64(qps),
+		rate:   float64(qps),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// TryAccept implements RateLimiter.
+func (t *tokenBucketRateLimiter) TryAccept() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	t.advance(now)
+
+	if now.Before(t.last) || t.tokens < 1 {
+		return false
+	}
+
+	t.tokens--
+
+	return true
+}
+
+// Accept implements RateLimiter.
+func (t *tokenBucketRateLimiter) Accept() {
+	_ = t.Wait(context.Background())
+}
+
+// Wait implements RateLimiter.
+func (t *tokenBucketRateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	wait := t.reserve()
+	if wait <= 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		t.cancel()
+		return fmt.Errorf("rate limiter wait of %v would exceed context deadline", wait)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		return ctx.Err()
+	}
+}
+
+// QPS implements RateLimiter.
+func (t *tokenBucketRateLimiter) QPS() float32 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return float32(t.rate)
+}
+
+// Throttled implements AdaptiveRateLimiter.
+func (t *tokenBucketRateLimiter) Throttled(retryAfter time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	t.advance(now)
+
+	t.rate = max(t.rate/2, t.qps/minRateFraction)
+	t.tokens = min(t.tokens, 0)
+
+	if resume := now.Add(retryAfter); resume.After(t.last) {
+		t.last = resume
+	}
+}
+
+// Succeeded implements AdaptiveRateLimiter.
+func (t *tokenBucketRateLimiter) Succeeded() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.rate >= t.qps {
+		return
+	}
+
+	t.advance(time.Now())
+	t.rate = min(t.rate+t.qps*recoveryFraction, t.qps)
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (t *tokenBucketRateLimiter) reserve() time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	t.advance(now)
+
+	t.tokens--
+
+	available := t.last
+	if t.tokens < 0 {
+		available = available.Add(time.Duration(-t.tokens / t.rate * float64(time.Second)))
+	}
+
+	return available.Sub(now)
+}
+
+// cancel gives back a reserved token which was not used.
+func (t *tokenBucketRateLimiter) cancel() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.tokens = min(t.tokens+1, t.burst)
+}
+
+// advance refills the bucket up to now. It must be called with the lock held.
+func (t *tokenBucketRateLimiter) advance(now time.Time) {
+	if !now.After(t.last) {
+		return
+	}
+
+	t.tokens = min(t.tokens+now.Sub(t.last).Seconds()*t.rate, t.burst)
+	t.last = now
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package flowcontrol
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTryAccept(t *testing.T) {
+	r := NewTokenBucketRateLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if !r.TryAccept() {
+			t.Fatalf("token %d: expected to be accepted within the burst", i)
+		}
+	}
+
+	if r.TryAccept() {
+		t.Error("expected the bucket to be empty")
+	}
+}
+
+func TestWait(t *testing.T) {
+	r := NewTokenBucketRateLimiter(100, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := r.Wait(context.TODO()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// the burst token is free, the two others take 10ms each
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("expected requests to be paced, took %v", elapsed)
+	}
+}
+
+func TestWaitDeadline(t *testing.T) {
+	r := NewTokenBucketRateLimiter(1, 1)
+	r.Accept()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := r.Wait(ctx); err == nil {
+		t.Fatal("expected an error when the wait exceeds the deadline")
+	}
+
+	// the token reserved by the failed wait is given back
+	time.Sleep(time.Second)
+
+	if !r.TryAccept() {
+		t.Error("expected a token after refill")
+	}
+}
+
+func TestThrottled(t *testing.T) {
+	r := NewTokenBucketRateLimiter(10, 10)
+
+	r.Throttled(50 * time.Millisecond)
+
+	if got := r.QPS(); got != 5 {
+		t.Errorf("expected qps to be halved to 5, got %v", got)
+	}
+
+	if r.TryAccept() {
+		t.Error("expected no token before retry after elapsed")
+	}
+
+	for i := 0; i < 10; i++ {
+		r.Throttled(0)
+	}
+
+	if got := r.QPS(); got != 10.0/minRateFraction {
+		t.Errorf("expected qps floor of %v, got %v", 10.0/minRateFraction, got)
+	}
+
+	for i := 0; i < 20; i++ {
+		r.Succeeded()
+	}
+
+	if got := r.QPS(); got != 10 {
+		t.Errorf("expected qps to recover to 10, got %v", got)
+	}
+}
@@ -0,0 +1,7 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package watch contains a generic watchable interface, and a stream watcher
+// which turns a decoded event stream into a watch.Interface.
+package watch // import "github.com/coding-hui/wecoding-sdk-go/watch"
@@ -0,0 +1,161 @@
+// Copyright (c) 2023 coding-hui. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package watch
+
+import (
+	"sync"
+)
+
+// Interface can be implemented by anything that knows how to watch and report changes.
+type Interface interface {
+	// Stop stops watching. Will close the channel returned by ResultChan(). Releases
+	// any resources used by the watch.
+	Stop()
+
+	// ResultChan returns a chan which will receive all the events. If an error occurs
+	// or Stop() is called, the implementation will close this channel and
+	// release any resources used by the watch.
+	ResultChan() <-chan Event
+}
+
+// EventType defines the possible types of events.
+type EventType string
+
+// Defines the possible types of events.
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+	Error    EventType = "ERROR"
+)
+
+// Event represents a single event to a watched resource.
+type Event struct {
+	Type EventType
+
+	// Object is:
+	//  * If Type is Added or Modified: the new state of the object.
+	//  * If Type is Deleted: the state of the object immediately before deletion.
+	//  * If Type is Error: an error describing what went wrong.
+	Object interface{}
+}
+
+// emptyWatch is a watch.Interface which never delivers any event.
+type emptyWatch chan Event
+
+// NewEmptyWatch returns a watch interface that returns no results and is closed.
+// May be used in certain error conditions where no information is available but
+// an error is not warranted.
+func NewEmptyWatch() Interface {
+	ch := make(chan Event)
+	close(ch)
+
+	return emptyWatch(ch)
+}
+
+// Stop implements Interface.
+func (w emptyWatch) Stop() {
+}
+
+// ResultChan implements Interface.
+func (w emptyWatch) ResultChan() <-chan Event {
+	return chan Event(w)
+}
+
+// FakeWatcher lets you test anything that consumes a watch.Interface; threadsafe.
+type FakeWatcher struct {
+	result  chan Event
+	stopped bool
+	sync.Mutex
+}
+
+// NewFake returns a FakeWatcher with an unbuffered result channel.
+func NewFake() *FakeWatcher {
+	return &FakeWatcher{
+		result: make(chan Event),
+	}
+}
+
+// NewFakeWithChanSize returns a FakeWatcher with a result channel of the given size.
+func NewFakeWithChanSize(size int) *FakeWatcher {
+	return &FakeWatcher{
+		result: make(chan Event, size),
+	}
+}
+
+// Stop implements Interface.Stop().
+func (f *FakeWatcher) Stop() {
+	f.Lock()
+	defer f.Unlock()
+
+	if !f.stopped {
+		close(f.result)
+		f.stopped = true
+	}
+}
+
+// IsStopped returns whether Stop has been called.
+func (f *FakeWatcher) IsStopped() bool {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.stopped
+}
+
+// ResultChan implements Interface.ResultChan().
+func (f *FakeWatcher) ResultChan() <-chan Event {
+	return f.result
+}
+
+// Add sends an add event.
+func (f *FakeWatcher) Add(obj interface{}) {
+	f.Action(Added, obj)
+}
+
+// Modify sends a modify event.
+func (f *FakeWatcher) Modify(obj interface{}) {
+	f.Action(Modified, obj)
+}
+
+// Delete sends a delete event.
+func (f *FakeWatcher) Delete(lastValue interface{}) {
+	f.Action(Deleted, lastValue)
+}
+
+// Error sends an Error event.
+func (f *FakeWatcher) Error(err error) {
+	f.Action(Error, err)
+}
+
+// Action sends an event of the requested type, for table-based testing.
+// Events sent after Stop are dropped.
+func (f *FakeWatcher) Action(action EventType, obj interface{}) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.stopped {
+		return
+	}
+
+	f.result <- Event{Type: action, Object: obj}
+}
+
+// TryAction is like Action but does not block: it returns false, dropping the
+// event, when the buffer of the result channel is full.
+func (f *FakeWatcher) TryAction(action EventType, obj interface{}) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.stopped {
+		return true
+	}
+
+	select {
+	case f.result <- Event{Type: action, Object: obj}:
+		return true
+	default:
+		return false
+	}
+}